@@ -9,10 +9,10 @@ import (
 )
 
 var (
-	vtKey string
-	usKey string
-	avKey string
-	ghKey string
+	vtKey []string
+	usKey []string
+	avKey []string
+	ghKey []string
 )
 
 var configCmd = &cobra.Command{
@@ -21,24 +21,24 @@ var configCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		changed := false
 
-		if vtKey != "" {
+		if len(vtKey) > 0 {
 			viper.Set("api_keys.virustotal", vtKey)
-			fmt.Println("[+] Set VirusTotal key")
+			fmt.Printf("[+] Set %d VirusTotal key(s)\n", len(vtKey))
 			changed = true
 		}
-		if usKey != "" {
+		if len(usKey) > 0 {
 			viper.Set("api_keys.urlscan", usKey)
-			fmt.Println("[+] Set URLScan key")
+			fmt.Printf("[+] Set %d URLScan key(s)\n", len(usKey))
 			changed = true
 		}
-		if avKey != "" {
+		if len(avKey) > 0 {
 			viper.Set("api_keys.alienvault", avKey)
-			fmt.Println("[+] Set AlienVault key")
+			fmt.Printf("[+] Set %d AlienVault key(s)\n", len(avKey))
 			changed = true
 		}
-		if ghKey != "" {
+		if len(ghKey) > 0 {
 			viper.Set("api_keys.github", ghKey)
-			fmt.Println("[+] Set GitHub key")
+			fmt.Printf("[+] Set %d GitHub key(s)\n", len(ghKey))
 			changed = true
 		}
 
@@ -67,8 +67,9 @@ var configCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(configCmd)
 
-	configCmd.Flags().StringVar(&vtKey, "virustotal", "", "Set VirusTotal API Key")
-	configCmd.Flags().StringVar(&usKey, "urlscan", "", "Set URLScan API Key")
-	configCmd.Flags().StringVar(&avKey, "alienvault", "", "Set AlienVault OTX API Key")
-	configCmd.Flags().StringVar(&ghKey, "github", "", "Set GitHub API Token")
+	// Repeat a flag (or pass a comma-separated list) to store a key pool
+	configCmd.Flags().StringSliceVar(&vtKey, "virustotal", nil, "Set VirusTotal API Key(s)")
+	configCmd.Flags().StringSliceVar(&usKey, "urlscan", nil, "Set URLScan API Key(s)")
+	configCmd.Flags().StringSliceVar(&avKey, "alienvault", nil, "Set AlienVault OTX API Key(s)")
+	configCmd.Flags().StringSliceVar(&ghKey, "github", nil, "Set GitHub API Token(s)")
 }
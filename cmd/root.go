@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
+	"github.com/bratyabasu07/deflot/internal/archive"
 	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 	"github.com/bratyabasu07/deflot/internal/dedup"
 	"github.com/bratyabasu07/deflot/internal/filters"
 	"github.com/bratyabasu07/deflot/internal/integrations/jssecrethunter"
+	"github.com/bratyabasu07/deflot/internal/normalize"
 	"github.com/bratyabasu07/deflot/internal/output"
 	"github.com/bratyabasu07/deflot/internal/pipeline"
+	"github.com/bratyabasu07/deflot/internal/scope"
 	"github.com/bratyabasu07/deflot/internal/sources"
 	"github.com/bratyabasu07/deflot/internal/status"
 	"github.com/bratyabasu07/deflot/internal/summary"
@@ -47,12 +51,40 @@ var (
 	configFilterFlag  bool
 
 	// Scanners
-	jsScanFlag bool
+	jsScanFlag       bool
+	fetchArchiveFlag bool
 
 	// Advanced flags
 	wildcardFlag bool
 	noDedupFlag  bool
+	newOnlyFlag  bool
 	mcFlag       string
+	scopeFlag    string
+	logScopeFlag bool
+	probeFlag    bool
+
+	// Response filter flags
+	fcFlag           string
+	matchSizeFlag    string
+	filterSizeFlag   string
+	matchWordsFlag   string
+	filterWordsFlag  string
+	matchLinesFlag   string
+	filterLinesFlag  string
+	matchRegexFlag   string
+	filterRegexFlag  string
+	matchHeaderFlag  string
+	filterHeaderFlag string
+	calibrateFlag    bool
+
+	// Dedup backend flags
+	dedupModeFlag        string
+	bloomFPFlag          float64
+	bloomMaxMemFlag      int
+	structuralFlag       bool
+	templateSamplesFlag  int
+	paramDedupFlag       bool
+	ignoreParamOrderFlag bool
 
 	sourcesFlag    string
 	initConfigFlag bool
@@ -75,7 +107,7 @@ Features:
   - Streaming Pipeline: Low memory footprint even for massive targets
   - Smart Gates: Deduplication and HTTP Status checking
   - Classification: Automatically tags Secrets, Configs, Backups, Params, JS
-  - Modular Sources: VirusTotal, URLScan, OTX, AlienVault, GitHub, Wayback Machine
+  - Modular Sources: VirusTotal, URLScan, OTX, AlienVault, GitHub, Wayback Machine, Common Crawl, CT Logs
 `,
 	Example: `  # Basic scan of a domain
   deflot -d example.com -o ./results
@@ -83,6 +115,9 @@ Features:
   # Batch scan multiple targets from a file
   deflot -t targets.txt
 
+  # Read URLs from a pipe
+  cat urls.txt | deflot --sensitive-urls
+
   # Using the scan subcommand
   deflot scan -d example.com --json --stdout
 `,
@@ -104,6 +139,11 @@ func runScan(cmd *cobra.Command, args []string) {
 	}
 
 	// 2. Validation
+	// Piped input with no explicit target behaves like -i -
+	if domainFlag == "" && inputFlag == "" && targetListFlag == "" && stdinIsPiped() {
+		inputFlag = appCtx.StdinInput
+	}
+
 	if domainFlag == "" && inputFlag == "" && targetListFlag == "" {
 		cmd.Help()
 		fmt.Println("\n[!] Error: You must provide a target domain (-d), input file (-i), or target list (-t).")
@@ -140,6 +180,8 @@ func runScan(cmd *cobra.Command, args []string) {
 			} else {
 				targetName = domainFlag
 			}
+		} else if inputFlag == appCtx.StdinInput {
+			targetName = "stdin"
 		} else if inputFlag != "" {
 			// Extract filename without extension
 			base := filepath.Base(inputFlag)
@@ -189,28 +231,40 @@ func runScan(cmd *cobra.Command, args []string) {
 	}
 
 	// 4. Initialize Components
-	cfg := config.Config{ApiKeys: config.GetAPIKeys()}
+	cfg := config.Load()
+	if path := config.QuotaUsagePath(); path != "" {
+		sources.TrackDailyQuota(path)
+	}
 
 	// Managers
 	sourceMgr := sources.NewManager(appContext, cfg)
 
-	// API Sources (always registered, Manager handles enable/disable/keys)
-	sourceMgr.Register(sources.NewWayback(appContext.Domain))
-	sourceMgr.Register(sources.NewVirusTotal(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewURLScan(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewAlienVault(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewGitHub(appContext.Domain, cfg))
+	// Registry sources and configured exec sources (Manager handles enable/disable/keys)
+	sourceMgr.RegisterAll()
 
 	// Local Source
-	if inputFlag != "" {
+	if inputFlag == appCtx.StdinInput {
+		sourceMgr.Register(sources.NewStdinSource())
+	} else if inputFlag != "" {
 		sourceMgr.Register(sources.NewFileSource(inputFlag))
 	}
 
 	stats := summary.New()
 
 	// Utility Engines
-	deduplicator := dedup.New(appContext.Domain, appContext.Wildcard, appContext.NoDedup)
-	checker := status.New(appContext.Timeout, appContext.Match)
+	history := openHistory(appContext)
+	if history != nil {
+		defer history.Close()
+	}
+	programScope, scopeLog := openScope(appContext)
+	if scopeLog != nil {
+		defer scopeLog.Close()
+	}
+	deduplicator := dedup.New(appContext.Domain, appContext.Wildcard, appContext.NoDedup, dedupOptions(appContext, history, programScope, scopeLog)...)
+	checker := status.New(appContext.Timeout, appContext.Match, probeFlag, responseFilters())
+	if programScope != nil {
+		checker.SetScope(deduplicator.InScope)
+	}
 	filterEngine := filters.New(appContext.Filters)
 	flasher := ui.NewFlasher(jsonFlag, stdoutFlag)
 
@@ -227,7 +281,13 @@ func runScan(cmd *cobra.Command, args []string) {
 		fmt.Println("[!] Warning: JSSecretHunter not found. Run tools_install.sh to install.")
 	}
 
-	pipe := pipeline.New(appContext, deduplicator, checker, filterEngine, writer, stats, flasher.Notify, jsScanner)
+	archiver := archive.New(fetchArchiveFlag, appContext.OutputDir)
+	if fetchArchiveFlag && len(appContext.Match) == 0 {
+		fmt.Println("[!] Warning: --fetch-archived needs --mc to tell dead URLs apart.")
+	}
+	defer archiver.Close()
+
+	pipe := pipeline.New(appContext, deduplicator, checker, filterEngine, writer, stats, flasher.Notify, jsScanner, archiver, normalizeRules(cfg.Normalize))
 
 	// 5. Execution Flow
 	ctx := context.Background()
@@ -251,6 +311,29 @@ func runScan(cmd *cobra.Command, args []string) {
 	done := pipe.Start(ctx, rawChan)
 
 	<-done
+	if err := writer.WriteSightings(deduplicator); err != nil {
+		fmt.Printf("[!] Output Error: %v\n", err)
+	}
+	if err := writer.WriteTemplates(deduplicator); err != nil {
+		fmt.Printf("[!] Output Error: %v\n", err)
+	}
+	recordSourceUsage(stats, sourceMgr)
+	if history != nil {
+		stats.SetHistory(deduplicator.HistoryCounts())
+	}
+	if !appContext.NoDedup {
+		mem := deduplicator.Memory()
+		stats.SetDedupMemory(mem.Mode, mem.Bytes, mem.FPRate, mem.Saturated)
+	}
+	if templates, collapsed := deduplicator.Templates(); templates != nil {
+		stats.SetTemplates(uint64(len(templates)), collapsed)
+	}
+	if scopeLog != nil {
+		stats.SetOutOfScope(scopeLog.Count())
+	}
+	if calibrateFlag {
+		recordCalibration(stats, checker)
+	}
 	stats.PrintReport()
 	ui.PrintOutro(jsonFlag, stdoutFlag)
 }
@@ -270,9 +353,9 @@ func runBatchScan(cmd *cobra.Command, args []string) {
 	// Process each target sequentially
 	for idx, target := range targets {
 		targetNum := idx + 1
-		fmt.Printf("\\n" + strings.Repeat("=", 70) + "\\n")
+		fmt.Print("\\n" + strings.Repeat("=", 70) + "\\n")
 		fmt.Printf("[Target %d/%d] Starting scan: %s\\n", targetNum, totalTargets, target)
-		fmt.Printf(strings.Repeat("=", 70) + "\\n\\n")
+		fmt.Print(strings.Repeat("=", 70) + "\\n\\n")
 
 		// Temporarily set the domain flag for this specific target
 		originalDomain := domainFlag
@@ -335,9 +418,9 @@ func runBatchScan(cmd *cobra.Command, args []string) {
 		fmt.Printf("\\n[✓] Completed target %d/%d: %s\\n", targetNum, totalTargets, target)
 	}
 
-	fmt.Printf("\\n" + strings.Repeat("=", 70) + "\\n")
+	fmt.Print("\\n" + strings.Repeat("=", 70) + "\\n")
 	fmt.Printf("[✓] Batch scan complete! Processed %d targets.\\n", totalTargets)
-	fmt.Printf(strings.Repeat("=", 70) + "\\n\\n")
+	fmt.Print(strings.Repeat("=", 70) + "\\n\\n")
 }
 
 // runSingleTargetScan executes the scan logic for a single target.
@@ -351,6 +434,8 @@ func runSingleTargetScan() {
 			} else {
 				targetName = domainFlag
 			}
+		} else if inputFlag == appCtx.StdinInput {
+			targetName = "stdin"
 		} else if inputFlag != "" {
 			base := filepath.Base(inputFlag)
 			ext := filepath.Ext(base)
@@ -396,22 +481,34 @@ func runSingleTargetScan() {
 		os.Exit(1)
 	}
 
-	cfg := config.Config{ApiKeys: config.GetAPIKeys()}
+	cfg := config.Load()
+	if path := config.QuotaUsagePath(); path != "" {
+		sources.TrackDailyQuota(path) // shared by every target of the batch
+	}
 	sourceMgr := sources.NewManager(appContext, cfg)
 
-	sourceMgr.Register(sources.NewWayback(appContext.Domain))
-	sourceMgr.Register(sources.NewVirusTotal(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewURLScan(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewAlienVault(appContext.Domain, cfg))
-	sourceMgr.Register(sources.NewGitHub(appContext.Domain, cfg))
+	sourceMgr.RegisterAll()
 
-	if inputFlag != "" {
+	if inputFlag == appCtx.StdinInput {
+		sourceMgr.Register(sources.NewStdinSource())
+	} else if inputFlag != "" {
 		sourceMgr.Register(sources.NewFileSource(inputFlag))
 	}
 
 	stats := summary.New()
-	deduplicator := dedup.New(appContext.Domain, appContext.Wildcard, appContext.NoDedup)
-	checker := status.New(appContext.Timeout, appContext.Match)
+	history := openHistory(appContext)
+	if history != nil {
+		defer history.Close()
+	}
+	programScope, scopeLog := openScope(appContext)
+	if scopeLog != nil {
+		defer scopeLog.Close()
+	}
+	deduplicator := dedup.New(appContext.Domain, appContext.Wildcard, appContext.NoDedup, dedupOptions(appContext, history, programScope, scopeLog)...)
+	checker := status.New(appContext.Timeout, appContext.Match, probeFlag, responseFilters())
+	if programScope != nil {
+		checker.SetScope(deduplicator.InScope)
+	}
 	filterEngine := filters.New(appContext.Filters)
 	flasher := ui.NewFlasher(jsonFlag, stdoutFlag)
 
@@ -427,7 +524,13 @@ func runSingleTargetScan() {
 		fmt.Println("[!] Warning: JSSecretHunter not found. Run tools_install.sh to install.")
 	}
 
-	pipe := pipeline.New(appContext, deduplicator, checker, filterEngine, writer, stats, flasher.Notify, jsScanner)
+	archiver := archive.New(fetchArchiveFlag, appContext.OutputDir)
+	if fetchArchiveFlag && len(appContext.Match) == 0 {
+		fmt.Println("[!] Warning: --fetch-archived needs --mc to tell dead URLs apart.")
+	}
+	defer archiver.Close()
+
+	pipe := pipeline.New(appContext, deduplicator, checker, filterEngine, writer, stats, flasher.Notify, jsScanner, archiver, normalizeRules(cfg.Normalize))
 
 	ctx := context.Background()
 	fmt.Printf("[*] Target: %s\\n", appContext.Domain)
@@ -442,9 +545,261 @@ func runSingleTargetScan() {
 	done := pipe.Start(ctx, rawChan)
 
 	<-done
+	if err := writer.WriteSightings(deduplicator); err != nil {
+		fmt.Printf("[!] Output Error: %v\n", err)
+	}
+	if err := writer.WriteTemplates(deduplicator); err != nil {
+		fmt.Printf("[!] Output Error: %v\n", err)
+	}
+	recordSourceUsage(stats, sourceMgr)
+	if history != nil {
+		stats.SetHistory(deduplicator.HistoryCounts())
+	}
+	if !appContext.NoDedup {
+		mem := deduplicator.Memory()
+		stats.SetDedupMemory(mem.Mode, mem.Bytes, mem.FPRate, mem.Saturated)
+	}
+	if templates, collapsed := deduplicator.Templates(); templates != nil {
+		stats.SetTemplates(uint64(len(templates)), collapsed)
+	}
+	if scopeLog != nil {
+		stats.SetOutOfScope(scopeLog.Count())
+	}
+	if calibrateFlag {
+		recordCalibration(stats, checker)
+	}
 	stats.PrintReport()
 }
 
+// openHistory loads the cross-run URL history kept in the output directory.
+// Without dedup there is nothing to compare against, so it is skipped.
+func openHistory(appContext *appCtx.AppContext) *dedup.Store {
+	if appContext.OutputDir == "" || appContext.NoDedup {
+		if newOnlyFlag {
+			fmt.Println("[!] Warning: --new-only needs an output directory and dedup enabled; ignoring.")
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(appContext.OutputDir, 0755); err != nil {
+		fmt.Printf("[!] History Error: %v\n", err)
+		return nil
+	}
+
+	path := filepath.Join(appContext.OutputDir, "history.txt")
+	var store *dedup.Store
+	var err error
+	if dedupModeFlag == "bloom" {
+		store, err = dedup.OpenBloomStore(path, bloomFPFlag, bloomShare(appContext))
+	} else {
+		store, err = dedup.OpenStore(path)
+	}
+	if err != nil {
+		fmt.Printf("[!] History Error: %v\n", err)
+		return nil
+	}
+	if newOnlyFlag {
+		fmt.Printf("[*] New-only mode: skipping %d URLs from earlier scans\n", store.Loaded())
+	}
+	return store
+}
+
+// openScope loads the --scope file and opens out_of_scope.txt. A scope file
+// that fails to parse is fatal: scanning without it could touch assets the
+// program forbids.
+func openScope(appContext *appCtx.AppContext) (*scope.Scope, *scope.Log) {
+	var sc *scope.Scope
+	if scopeFlag != "" {
+		loaded, err := scope.Load(scopeFlag)
+		if err != nil {
+			fmt.Printf("[!] Scope Error: %v\n", err)
+			os.Exit(1)
+		}
+		sc = loaded
+		fmt.Printf("[*] Scope: %s\n", scopeFlag)
+	}
+
+	if !logScopeFlag {
+		return sc, nil
+	}
+	if appContext.OutputDir == "" {
+		fmt.Println("[!] Warning: --out-of-scope needs an output directory; ignoring.")
+		return sc, nil
+	}
+	if err := os.MkdirAll(appContext.OutputDir, 0755); err != nil {
+		fmt.Printf("[!] Scope Error: %v\n", err)
+		return sc, nil
+	}
+
+	log, err := scope.OpenLog(filepath.Join(appContext.OutputDir, "out_of_scope.txt"))
+	if err != nil {
+		fmt.Printf("[!] Scope Error: %v\n", err)
+		return sc, nil
+	}
+	return sc, log
+}
+
+// bloomShare splits --bloom-max-mem evenly between what bloom mode bounds:
+// the filter itself, the history and the structural templates.
+func bloomShare(appContext *appCtx.AppContext) uint64 {
+	parts := uint64(1)
+	if appContext.OutputDir != "" && !appContext.NoDedup {
+		parts++
+	}
+	if structuralFlag {
+		parts++
+	}
+	return (uint64(bloomMaxMemFlag) << 20) / parts
+}
+
+// dedupOptions builds the dedup backend, history and scope settings from the flags.
+func dedupOptions(appContext *appCtx.AppContext, store *dedup.Store, sc *scope.Scope, scopeLog *scope.Log) []dedup.Option {
+	var opts []dedup.Option
+	if store != nil {
+		opts = append(opts, dedup.WithStore(store, newOnlyFlag))
+	}
+	if sc != nil || scopeLog != nil {
+		opts = append(opts, dedup.WithScope(sc, scopeLog))
+	}
+
+	var budget uint64 // per bounded structure in bloom mode; 0 leaves templates uncapped
+	switch dedupModeFlag {
+	case "", "exact":
+	case "bloom":
+		budget = bloomShare(appContext)
+		opts = append(opts, dedup.WithBloom(bloomFPFlag, budget))
+	default:
+		fmt.Printf("[!] Unknown --dedup-mode %q, using exact\n", dedupModeFlag)
+	}
+
+	if structuralFlag {
+		opts = append(opts, dedup.WithTemplates(templateSamplesFlag, budget))
+	}
+	if paramDedupFlag {
+		opts = append(opts, dedup.WithParamKey(ignoreParamOrderFlag))
+	} else if ignoreParamOrderFlag {
+		fmt.Println("[!] Warning: --ignore-param-order only applies with --param-dedup; ignoring.")
+	}
+	return opts
+}
+
+// responseFilters parses the response filter flags. Bad values are fatal
+// rather than silently letting everything through.
+func responseFilters() status.Filters {
+	var f status.Filters
+	var err error
+
+	fail := func(flag string, err error) {
+		fmt.Printf("[!] Error: --%s: %v\n", flag, err)
+		os.Exit(1)
+	}
+
+	if f.FilterCodes, err = status.ParseCodes(fcFlag); err != nil {
+		fail("fc", err)
+	}
+
+	ranges := []struct {
+		flag  string
+		value string
+		dst   *[]status.Range
+	}{
+		{"ms", matchSizeFlag, &f.MatchSize},
+		{"fs", filterSizeFlag, &f.FilterSize},
+		{"mw", matchWordsFlag, &f.MatchWords},
+		{"fw", filterWordsFlag, &f.FilterWords},
+		{"ml", matchLinesFlag, &f.MatchLines},
+		{"fl", filterLinesFlag, &f.FilterLines},
+	}
+	for _, r := range ranges {
+		if *r.dst, err = status.ParseRanges(r.value); err != nil {
+			fail(r.flag, err)
+		}
+	}
+
+	regexes := []struct {
+		flag  string
+		value string
+		dst   **regexp.Regexp
+	}{
+		{"match-regex", matchRegexFlag, &f.MatchRegex},
+		{"filter-regex", filterRegexFlag, &f.FilterRegex},
+		{"match-header", matchHeaderFlag, &f.MatchHeader},
+		{"filter-header", filterHeaderFlag, &f.FilterHeader},
+	}
+	for _, r := range regexes {
+		if r.value == "" {
+			continue
+		}
+		if *r.dst, err = regexp.Compile(r.value); err != nil {
+			fail(r.flag, err)
+		}
+	}
+
+	f.AutoCalibrate = calibrateFlag
+	return f
+}
+
+// normalizeRules maps the config's normalize section onto the normalizer.
+func normalizeRules(settings config.NormalizeSettings) normalize.Rules {
+	rules := normalize.Rules{
+		SortQuery:       settings.SortQuery,
+		PercentEncoding: settings.PercentEncoding,
+		CleanPath:       settings.CleanPath,
+		StripTracking:   settings.StripTracking,
+		TrackingParams:  settings.TrackingParams,
+		TrailingSlash:   strings.ToLower(settings.TrailingSlash),
+	}
+	switch rules.TrailingSlash {
+	case "", normalize.SlashKeep, normalize.SlashStrip:
+	default:
+		fmt.Printf("[!] Unknown normalize.trailing_slash %q, keeping slashes\n", settings.TrailingSlash)
+		rules.TrailingSlash = normalize.SlashKeep
+	}
+	// A sorted query would make --param-dedup ignore order regardless of
+	// --ignore-param-order, so leave ordering to the param key
+	if paramDedupFlag && !ignoreParamOrderFlag {
+		rules.SortQuery = false
+	}
+	return rules
+}
+
+// recordSourceUsage copies per-source API consumption into the final summary.
+func recordSourceUsage(stats *summary.Stats, mgr *sources.Manager) {
+	for _, u := range mgr.Usage() {
+		stats.AddSourceUsage(summary.SourceUsage{
+			Name:           u.Name,
+			Calls:          u.Calls,
+			Retries:        u.Retries,
+			QuotaExhausted: u.QuotaExhausted,
+		})
+	}
+}
+
+// recordCalibration copies the per-host soft-404 results into the final summary.
+func recordCalibration(stats *summary.Stats, checker *status.Checker) {
+	hosts := checker.Calibrations()
+	var catchAll []summary.CatchAllHost
+	for _, h := range hosts {
+		if h.CatchAll {
+			catchAll = append(catchAll, summary.CatchAllHost{
+				Host:        h.Host,
+				Fingerprint: h.Fingerprint,
+				Dropped:     h.Dropped,
+			})
+		}
+	}
+	stats.SetCalibration(len(hosts), catchAll)
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal.
+func stdinIsPiped() bool {
+	fileInfo, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return (fileInfo.Mode() & os.ModeCharDevice) == 0
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
@@ -459,16 +814,42 @@ func init() {
 
 	// REQUIRED
 	rootCmd.PersistentFlags().StringVarP(&domainFlag, "domain", "d", "", "Target domain to scan (e.g., example.com)")
-	rootCmd.PersistentFlags().StringVarP(&inputFlag, "input", "i", "", "Input file containing URLs or subdomains")
+	rootCmd.PersistentFlags().StringVarP(&inputFlag, "input", "i", "", "Input file containing URLs or subdomains (- for stdin)")
 	rootCmd.PersistentFlags().StringVarP(&targetListFlag, "target-list", "t", "", "File containing list of target domains (one per line)")
 
 	// ADVANCED
 	rootCmd.PersistentFlags().BoolVar(&wildcardFlag, "wildcard", false, "Enable wildcard subdomain handling")
 	rootCmd.PersistentFlags().BoolVar(&noDedupFlag, "no-dedup", false, "Disable deduplication")
+	rootCmd.PersistentFlags().BoolVar(&newOnlyFlag, "new-only", false, "Only output URLs not seen by earlier scans of the same output directory")
+	rootCmd.PersistentFlags().StringVar(&dedupModeFlag, "dedup-mode", "exact", "Dedup backend: exact (map) or bloom (memory-bounded, probabilistic)")
+	rootCmd.PersistentFlags().Float64Var(&bloomFPFlag, "bloom-fp", 0.001, "False-positive rate for --dedup-mode bloom")
+	rootCmd.PersistentFlags().IntVar(&bloomMaxMemFlag, "bloom-max-mem", 256, "Memory ceiling in MB for --dedup-mode bloom")
+	rootCmd.PersistentFlags().BoolVar(&structuralFlag, "structural", false, "Collapse URLs that differ only in numeric/UUID/hash/date path segments")
+	rootCmd.PersistentFlags().IntVar(&templateSamplesFlag, "template-samples", 3, "URLs kept per template with --structural")
+	rootCmd.PersistentFlags().BoolVar(&paramDedupFlag, "param-dedup", false, "Treat URLs with the same path and parameter names as duplicates, ignoring values")
+	rootCmd.PersistentFlags().BoolVar(&ignoreParamOrderFlag, "ignore-param-order", false, "With --param-dedup, also ignore the order of parameters")
 	rootCmd.PersistentFlags().StringVar(&mcFlag, "mc", "", "Match Status Codes (e.g., 200,403,404)")
+	rootCmd.PersistentFlags().BoolVar(&probeFlag, "probe", false, "GET every URL and record title, size, content type, server and redirects (JSON output)")
+
+	rootCmd.PersistentFlags().StringVar(&scopeFlag, "scope", "", "Scope file with include/exclude rules (hosts, *.wildcards, CIDRs, /paths, re:regex; ! to exclude)")
+	rootCmd.PersistentFlags().BoolVar(&logScopeFlag, "out-of-scope", false, "Write dropped out-of-scope URLs and the rule that excluded them to out_of_scope.txt")
 	rootCmd.PersistentFlags().StringVar(&sourcesFlag, "sources", "", "Comma-separated list of sources to use")
 	rootCmd.PersistentFlags().BoolVar(&initConfigFlag, "init-config", false, "Create a default configuration file")
 
+	// RESPONSE FILTERS (evaluated in the status gate)
+	rootCmd.PersistentFlags().StringVar(&fcFlag, "fc", "", "Filter out status codes (e.g., 404,500)")
+	rootCmd.PersistentFlags().StringVar(&matchSizeFlag, "ms", "", "Match response size in bytes (e.g., 100-5000,8000-)")
+	rootCmd.PersistentFlags().StringVar(&filterSizeFlag, "fs", "", "Filter out response sizes in bytes (e.g., 0,1234)")
+	rootCmd.PersistentFlags().StringVar(&matchWordsFlag, "mw", "", "Match response word count (ranges like --ms)")
+	rootCmd.PersistentFlags().StringVar(&filterWordsFlag, "fw", "", "Filter out response word counts")
+	rootCmd.PersistentFlags().StringVar(&matchLinesFlag, "ml", "", "Match response line count (ranges like --ms)")
+	rootCmd.PersistentFlags().StringVar(&filterLinesFlag, "fl", "", "Filter out response line counts")
+	rootCmd.PersistentFlags().StringVar(&matchRegexFlag, "match-regex", "", "Only keep responses whose body matches this regex")
+	rootCmd.PersistentFlags().StringVar(&filterRegexFlag, "filter-regex", "", "Drop responses whose body matches this regex")
+	rootCmd.PersistentFlags().StringVar(&matchHeaderFlag, "match-header", "", "Only keep responses whose headers match this regex")
+	rootCmd.PersistentFlags().StringVar(&filterHeaderFlag, "filter-header", "", "Drop responses whose headers match this regex")
+	rootCmd.PersistentFlags().BoolVar(&calibrateFlag, "calibrate", false, "Fingerprint each host's response to random paths and drop soft-404/catch-all matches")
+
 	// OUTPUT
 	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "", "Output directory for results")
 	rootCmd.PersistentFlags().BoolVar(&jsonFlag, "json", false, "Output in JSON Lines format")
@@ -490,6 +871,7 @@ func init() {
 
 	// SCANNERS
 	rootCmd.PersistentFlags().BoolVar(&jsScanFlag, "js-scan", false, "Run JSSecretHunter on discovered JS files")
+	rootCmd.PersistentFlags().BoolVar(&fetchArchiveFlag, "fetch-archived", false, "Download the latest Wayback snapshot of dead secret/config/backup/JS URLs")
 
 	cobra.OnInitialize(config.Init)
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	"github.com/bratyabasu07/deflot/internal/dedup"
+	"github.com/bratyabasu07/deflot/internal/normalize"
+)
+
+func TestParamDedupKeepsParameterOrder(t *testing.T) {
+	defer func(param, ignore bool) { paramDedupFlag, ignoreParamOrderFlag = param, ignore }(paramDedupFlag, ignoreParamOrderFlag)
+
+	settings := config.NormalizeSettings{SortQuery: true, PercentEncoding: true, CleanPath: true, StripTracking: true}
+	urls := []string{"https://example.com/item?b=1&a=2", "https://example.com/item?a=2&b=1"}
+
+	for _, ignoreOrder := range []bool{false, true} {
+		paramDedupFlag, ignoreParamOrderFlag = true, ignoreOrder
+		rules := normalizeRules(settings)
+		d := dedup.New("example.com", false, false, dedup.WithParamKey(ignoreOrder))
+
+		passed := 0
+		for _, u := range urls {
+			res, err := normalize.Validate(u, rules)
+			if err != nil {
+				t.Fatalf("Validate(%q): %v", u, err)
+			}
+			if d.Check(res.Key) == dedup.Pass {
+				passed++
+			}
+		}
+		if want := map[bool]int{false: 2, true: 1}[ignoreOrder]; passed != want {
+			t.Errorf("ignoreOrder=%v: expected %d URLs to pass, got %d", ignoreOrder, want, passed)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+	"github.com/bratyabasu07/deflot/internal/sources"
+
+	"github.com/spf13/cobra"
+)
+
+var sourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "Inspect available data sources",
+}
+
+var sourcesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered sources and their API key status",
+	Run:   runSourcesList,
+}
+
+func runSourcesList(cmd *cobra.Command, args []string) {
+	cfg := config.Load()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKEY\tDEFAULT\tDESCRIPTION")
+
+	for _, info := range sources.Catalog(cfg) {
+		keyStatus := "-"
+		if info.KeyName != "" {
+			keyStatus = "missing"
+			if n := len(cfg.ApiKeys.Get(info.KeyName)); n == 1 {
+				keyStatus = "set"
+			} else if n > 1 {
+				keyStatus = fmt.Sprintf("set (%d)", n)
+			}
+		}
+
+		enabled := "off"
+		if info.DefaultEnabled {
+			enabled = "on"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.Name, keyStatus, enabled, info.Description)
+	}
+
+	w.Flush()
+}
+
+var sourcesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Validate API keys and reachability of every source",
+	Long: `Performs one minimal request per source and reports OK, BAD KEY,
+RATE LIMITED or UNREACHABLE with latency. Exits non-zero on any failure,
+so it can be used as a pre-flight check. Honours -d and --sources.`,
+	Run: runSourcesTest,
+}
+
+func runSourcesTest(cmd *cobra.Command, args []string) {
+	domain := domainFlag
+	if domain == "" {
+		domain = "example.com"
+	}
+
+	appContext, err := appCtx.New(
+		domain, "", false, "", sourcesFlag,
+		1, 0, timeoutFlag, false, "",
+		false, false, appCtx.FilterConfig{},
+	)
+	if err != nil {
+		fmt.Printf("[!] Initialization Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg := config.Load()
+	sourceMgr := sources.NewManager(appContext, cfg)
+	sourceMgr.RegisterAll()
+
+	results := sourceMgr.TestAll(context.Background())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tLATENCY\tDETAIL")
+
+	failed := 0
+	for _, res := range results {
+		latency := "-"
+		if res.Latency > 0 {
+			latency = res.Latency.Round(time.Millisecond).String()
+		}
+
+		detail := ""
+		if res.Err != nil {
+			detail = res.Err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.Name, res.Status, latency, detail)
+		if res.Failed() {
+			failed++
+		}
+	}
+	w.Flush()
+
+	if failed > 0 {
+		fmt.Printf("\n[!] %d source(s) failed pre-flight\n", failed)
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(sourcesCmd)
+	sourcesCmd.AddCommand(sourcesListCmd)
+	sourcesCmd.AddCommand(sourcesTestCmd)
+}
@@ -0,0 +1,225 @@
+package archive
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/filters"
+)
+
+const (
+	cdxURL      = "http://web.archive.org/cdx/search/cdx"
+	snapshotURL = "https://web.archive.org/web"
+	// Leaked files worth reading are small; skip anything bigger.
+	maxSnapshotSize = 10 * 1024 * 1024
+	// MaxConcurrent caps downloads in flight; archive.org throttles
+	// aggressive clients.
+	MaxConcurrent = 4
+)
+
+var errTooLarge = fmt.Errorf("snapshot larger than %d bytes", maxSnapshotSize)
+
+// Fetcher downloads the latest Wayback snapshot of URLs that are no longer live.
+type Fetcher struct {
+	enabled bool
+	dir     string
+	client  *http.Client
+
+	cdxURL      string
+	snapshotURL string
+
+	sem   chan struct{}
+	mu    sync.Mutex
+	index *os.File
+}
+
+// New creates a fetcher writing into <outputDir>/archived/.
+func New(enabled bool, outputDir string) *Fetcher {
+	if outputDir == "" {
+		enabled = false
+	}
+
+	return &Fetcher{
+		enabled:     enabled,
+		dir:         filepath.Join(outputDir, "archived"),
+		client:      &http.Client{Timeout: 60 * time.Second}, // archive.org is slow to serve old captures
+		cdxURL:      cdxURL,
+		snapshotURL: snapshotURL,
+		sem:         make(chan struct{}, MaxConcurrent),
+	}
+}
+
+// Enabled reports whether archived content should be fetched.
+func (f *Fetcher) Enabled() bool {
+	return f != nil && f.enabled
+}
+
+// Wants reports whether a category is worth recovering from the archive.
+func Wants(category string) bool {
+	switch category {
+	case filters.CatSecret, filters.CatConfig, filters.CatBackup, filters.CatJS:
+		return true
+	}
+	return false
+}
+
+// Fetch saves the latest archived 200 capture of rawURL and records it in
+// archived/index.txt. It returns false if there was nothing to save.
+// Snapshots over maxSnapshotSize are skipped rather than saved cut short.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (bool, error) {
+	if !f.Enabled() {
+		return false, nil
+	}
+
+	f.sem <- struct{}{}
+	defer func() { <-f.sem }()
+
+	timestamp, original, err := f.latest(ctx, rawURL)
+	if err != nil || timestamp == "" {
+		return false, err
+	}
+
+	// id_ asks for the raw bytes, without the Wayback toolbar or URL rewriting
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%sid_/%s", f.snapshotURL, timestamp, original), nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return false, fmt.Errorf("snapshot status: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxSnapshotSize {
+		return false, errTooLarge
+	}
+
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return false, err
+	}
+
+	name := fileName(rawURL, timestamp)
+	path := filepath.Join(f.dir, name)
+
+	out, err := os.Create(path)
+	if err != nil {
+		return false, err
+	}
+	// One byte past the limit tells a full file from a cut one
+	n, err := io.Copy(out, io.LimitReader(resp.Body, maxSnapshotSize+1))
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && n > maxSnapshotSize {
+		err = errTooLarge
+	}
+	if err != nil {
+		os.Remove(path)
+		return false, err
+	}
+
+	if err := f.record(rawURL, timestamp, filepath.Join("archived", name)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// latest looks up the most recent successful capture of rawURL.
+func (f *Fetcher) latest(ctx context.Context, rawURL string) (string, string, error) {
+	q := url.Values{}
+	q.Set("url", rawURL)
+	q.Set("output", "txt")
+	q.Set("fl", "timestamp,original")
+	q.Set("filter", "statuscode:200")
+	q.Set("limit", "-1") // negative limit counts from the newest capture
+
+	req, err := http.NewRequestWithContext(ctx, "GET", f.cdxURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", "", fmt.Errorf("cdx status: %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2); len(fields) == 2 {
+			return fields[0], fields[1], nil
+		}
+	}
+	return "", "", scanner.Err()
+}
+
+// record appends one "url<TAB>timestamp<TAB>path" line to the index.
+func (f *Fetcher) record(rawURL, timestamp, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.index == nil {
+		idx, err := os.OpenFile(filepath.Join(f.dir, "index.txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		f.index = idx
+	}
+
+	_, err := fmt.Fprintf(f.index, "%s\t%s\t%s\n", rawURL, timestamp, path)
+	return err
+}
+
+// Close flushes the index file.
+func (f *Fetcher) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.index == nil {
+		return nil
+	}
+	err := f.index.Close()
+	f.index = nil
+	return err
+}
+
+// fileName builds a readable, collision-free name for a snapshot.
+func fileName(rawURL, timestamp string) string {
+	base := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		base = u.Host + u.Path
+	}
+
+	safe := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSuffix(base, "/"))
+	if len(safe) > 120 {
+		safe = safe[len(safe)-120:]
+	}
+
+	sum := sha1.Sum([]byte(rawURL))
+	return fmt.Sprintf("%s_%s_%s", timestamp, safe, hex.EncodeToString(sum[:4]))
+}
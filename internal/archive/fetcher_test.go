@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestFetcher(t *testing.T, body func(w http.ResponseWriter)) (*Fetcher, string) {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cdx", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("20200101000000 " + r.URL.Query().Get("url") + "\n"))
+	})
+	mux.HandleFunc("/web/", func(w http.ResponseWriter, r *http.Request) {
+		body(w)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	f := New(true, dir)
+	f.cdxURL = srv.URL + "/cdx"
+	f.snapshotURL = srv.URL + "/web"
+	t.Cleanup(func() { f.Close() })
+	return f, dir
+}
+
+func TestFetchSavesSnapshot(t *testing.T) {
+	f, dir := newTestFetcher(t, func(w http.ResponseWriter) {
+		w.Write([]byte("DB_PASSWORD=hunter2\n"))
+	})
+
+	saved, err := f.Fetch(context.Background(), "https://example.com/.env")
+	if err != nil || !saved {
+		t.Fatalf("Expected snapshot to be saved, got %v, %v", saved, err)
+	}
+	f.Close()
+
+	index, _ := os.ReadFile(filepath.Join(dir, "archived", "index.txt"))
+	fields := strings.Split(strings.TrimSpace(string(index)), "\t")
+	if len(fields) != 3 || fields[0] != "https://example.com/.env" || fields[1] != "20200101000000" {
+		t.Fatalf("Unexpected index line: %q", index)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, fields[2]))
+	if string(data) != "DB_PASSWORD=hunter2\n" {
+		t.Errorf("Unexpected snapshot content: %q", data)
+	}
+}
+
+func TestFetchSkipsOversizedSnapshot(t *testing.T) {
+	// Streamed without a Content-Length, so only the read can tell
+	f, dir := newTestFetcher(t, func(w http.ResponseWriter) {
+		chunk := make([]byte, 1<<20)
+		for written := 0; written <= maxSnapshotSize; written += len(chunk) {
+			w.Write(chunk)
+			w.(http.Flusher).Flush()
+		}
+	})
+
+	saved, err := f.Fetch(context.Background(), "https://example.com/backup.zip")
+	if saved || err == nil {
+		t.Fatalf("Expected oversized snapshot to be skipped, got %v, %v", saved, err)
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "archived"))
+	if len(entries) != 0 {
+		t.Errorf("Expected no files left behind, got %d", len(entries))
+	}
+}
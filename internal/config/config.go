@@ -4,28 +4,191 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds the structure of the configuration file.
 type Config struct {
-	ApiKeys ApiKeys `mapstructure:"api_keys"`
+	ApiKeys    ApiKeys              `mapstructure:"api_keys"`
+	Sources    SourceSettings       `mapstructure:"sources"`
+	RateLimits map[string]RateLimit `mapstructure:"rate_limits"`
+	Normalize  NormalizeSettings    `mapstructure:"normalize"`
 }
 
+// ApiKeys holds a pool of keys per provider. A single string in the config
+// file (or a comma-separated list) decodes into a one-element pool.
 type ApiKeys struct {
-	VirusTotal string `mapstructure:"virustotal"`
-	URLScan    string `mapstructure:"urlscan"`
-	AlienVault string `mapstructure:"alienvault"`
-	GitHub     string `mapstructure:"github"`
+	VirusTotal []string `mapstructure:"virustotal"`
+	URLScan    []string `mapstructure:"urlscan"`
+	AlienVault []string `mapstructure:"alienvault"`
+	GitHub     []string `mapstructure:"github"`
+}
+
+// Get returns the non-empty keys stored under a config name (e.g. "virustotal").
+func (k ApiKeys) Get(name string) []string {
+	var pool []string
+	switch name {
+	case "virustotal":
+		pool = k.VirusTotal
+	case "urlscan":
+		pool = k.URLScan
+	case "alienvault":
+		pool = k.AlienVault
+	case "github":
+		pool = k.GitHub
+	}
+
+	var keys []string
+	for _, key := range pool {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// SourceSettings holds per-source tuning that is not an API key.
+type SourceSettings struct {
+	CommonCrawl CommonCrawlSettings `mapstructure:"commoncrawl"`
+	CT          CTSettings          `mapstructure:"ct"`
+	URLScan     URLScanSettings     `mapstructure:"urlscan"`
+	VirusTotal  VirusTotalSettings  `mapstructure:"virustotal"`
+	Wayback     WaybackSettings     `mapstructure:"wayback"`
+	Exec        []ExecSettings      `mapstructure:"exec"`
+}
+
+// CommonCrawlSettings configures the Common Crawl CDX index source.
+type CommonCrawlSettings struct {
+	// BaseURL is the index server root (collinfo.json and <id>-index live under it).
+	BaseURL string `mapstructure:"base_url"`
+	// Collections is how many of the most recent crawls are queried.
+	Collections int `mapstructure:"collections"`
+}
+
+// CTSettings configures the certificate-transparency log search source.
+type CTSettings struct {
+	// Endpoint is a crt.sh-compatible search URL that accepts q= and output=json.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// URLScanSettings configures the URLScan search source.
+type URLScanSettings struct {
+	// MaxResults caps how many results are paged through (0 = default cap).
+	MaxResults int `mapstructure:"max_results"`
+}
+
+// VirusTotalSettings configures what the VirusTotal source collects.
+type VirusTotalSettings struct {
+	// Mode is "subdomains", "urls" or "both".
+	Mode string `mapstructure:"mode"`
+}
+
+// WaybackSettings narrows and pages the Wayback Machine CDX query.
+type WaybackSettings struct {
+	// From and To bound capture dates; any prefix of yyyyMMddhhmmss works
+	// ("2019", "2021-06-01" and "20210601120000" are all accepted).
+	From string `mapstructure:"from"`
+	To   string `mapstructure:"to"`
+	// StatusCodes keeps captures with one of these codes ("200", "3..");
+	// entries starting with "!" exclude a code instead.
+	StatusCodes []string `mapstructure:"status_codes"`
+	// MimeTypes works like StatusCodes for the capture MIME type.
+	MimeTypes []string `mapstructure:"mime_types"`
+	// PageSize is how many captures are fetched per resumeKey page.
+	PageSize int `mapstructure:"page_size"`
+}
+
+// ExecSettings declares an external command whose stdout lines become URLs.
+type ExecSettings struct {
+	// Name is the source name used in --sources and in ScanRecord.Source.
+	Name string `mapstructure:"name"`
+	// Command is a template; {domain} is replaced with the target.
+	Command string `mapstructure:"command"`
+	// Timeout in seconds (0 = no limit beyond the scan itself).
+	Timeout int `mapstructure:"timeout"`
+}
+
+// RateLimit paces one source's outgoing API calls. Zero fields are unlimited.
+type RateLimit struct {
+	PerSecond float64 `mapstructure:"per_second"`
+	PerMinute float64 `mapstructure:"per_minute"`
+	// DailyQuota caps calls per calendar day across all scans and batch
+	// targets (usage is kept in ~/.deflot/quota_usage.json); the source
+	// stops once it is spent.
+	DailyQuota int `mapstructure:"daily_quota"`
+	// Burst is how many calls may go out back to back (default 1).
+	Burst int `mapstructure:"burst"`
+}
+
+// NormalizeSettings toggles the canonicalization applied to the key every
+// URL is deduplicated on; the URL probed and written is left as reported.
+// Keys missing from the config keep their defaults.
+type NormalizeSettings struct {
+	SortQuery       bool `mapstructure:"sort_query"`
+	PercentEncoding bool `mapstructure:"percent_encoding"`
+	// CleanPath collapses // and resolves ./ and ../ in paths.
+	CleanPath bool `mapstructure:"clean_path"`
+	// StripTracking drops utm_*, fbclid, gclid and friends, plus TrackingParams
+	// (a trailing * matches a prefix).
+	StripTracking  bool     `mapstructure:"strip_tracking"`
+	TrackingParams []string `mapstructure:"tracking_params"`
+	// TrailingSlash is "keep" or "strip".
+	TrailingSlash string `mapstructure:"trailing_slash"`
 }
 
 // defaultConfigFileContent defines the default YAML content.
-const defaultConfigFileContent = `api_keys:
+const defaultConfigFileContent = `# Each provider takes one key or a list; sources rotate to the next key
+# when one is rejected (401/403) or rate limited (429).
+api_keys:
   virustotal: ""
   urlscan: ""
   alienvault: ""
   github: ""
+sources:
+  commoncrawl:
+    base_url: "https://index.commoncrawl.org"
+    collections: 3
+  ct:
+    endpoint: "https://crt.sh/"
+  urlscan:
+    max_results: 10000
+  virustotal:
+    mode: "both"
+  wayback:
+    from: ""
+    to: ""
+    status_codes: []  # e.g. ["200", "!404"]
+    mime_types: []    # e.g. ["application/javascript"]
+    page_size: 10000
+  # External tools whose stdout lines are fed into the pipeline.
+  # exec:
+  #   - name: subfinder
+  #     command: "subfinder -d {domain} -silent"
+  #     timeout: 300
+# Per-source pacing; entries replace the built-in defaults for that source.
+rate_limits:
+  virustotal:
+    per_minute: 4
+    daily_quota: 500  # calls per calendar day, counted across scans
+  github:  # code search
+    per_minute: 30
+  github_content:  # file downloads behind search hits
+    per_minute: 60
+  otx:
+    per_second: 2
+  urlscan:
+    per_minute: 60
+# Canonicalization of the dedup key, so one endpoint yields one URL.
+# The URL that is probed and written keeps its original form.
+normalize:
+  sort_query: true
+  percent_encoding: true
+  clean_path: true
+  strip_tracking: true
+  tracking_params: []  # extra names, e.g. ["ref", "sessionid", "mkt_*"]
+  trailing_slash: "keep"  # or "strip"
 `
 
 // InitConfig initializes the configuration.
@@ -82,6 +245,26 @@ func GenerateDefaultConfig() {
 	fmt.Printf("Successfully created default config at %s\n", configPath)
 }
 
+// QuotaUsagePath is where daily_quota usage is kept between scans, or ""
+// when there is no home directory.
+func QuotaUsagePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".deflot", "quota_usage.json")
+}
+
+// Load returns the full configuration (API keys, source settings and rate limits).
+func Load() Config {
+	return Config{
+		ApiKeys:    GetAPIKeys(),
+		Sources:    GetSourceSettings(),
+		RateLimits: GetRateLimits(),
+		Normalize:  GetNormalizeSettings(),
+	}
+}
+
 // GetAPIKeys returns the API keys from the configuration.
 func GetAPIKeys() ApiKeys {
 	var keys ApiKeys
@@ -90,3 +273,38 @@ func GetAPIKeys() ApiKeys {
 	}
 	return keys
 }
+
+// GetSourceSettings returns the per-source settings from the configuration.
+func GetSourceSettings() SourceSettings {
+	var settings SourceSettings
+	if err := viper.UnmarshalKey("sources", &settings); err != nil {
+		return SourceSettings{}
+	}
+	return settings
+}
+
+// GetRateLimits returns the per-source rate limits from the configuration.
+func GetRateLimits() map[string]RateLimit {
+	var limits map[string]RateLimit
+	if err := viper.UnmarshalKey("rate_limits", &limits); err != nil {
+		return nil
+	}
+	return limits
+}
+
+// GetNormalizeSettings returns the canonicalization rules, starting from
+// the defaults so older config files get them too.
+func GetNormalizeSettings() NormalizeSettings {
+	defaults := NormalizeSettings{
+		SortQuery:       true,
+		PercentEncoding: true,
+		CleanPath:       true,
+		StripTracking:   true,
+		TrailingSlash:   "keep",
+	}
+	settings := defaults
+	if err := viper.UnmarshalKey("normalize", &settings); err != nil {
+		return defaults
+	}
+	return settings
+}
@@ -3,8 +3,12 @@ package context
 import (
 	"errors"
 	"strings"
+	"time"
 )
 
+// StdinInput is the InputFile value that selects standard input.
+const StdinInput = "-"
+
 // AppContext holds the immutable runtime state of the application.
 // It is passed down to all modules (Sources, Pipeline, Filters, Output).
 type AppContext struct {
@@ -51,6 +55,32 @@ type ScanRecord struct {
 	Source     string `json:"source"`
 	StatusCode int    `json:"http_status,omitempty"`
 	Category   string `json:"category"`
+	// LeakedURL is the URL as the source reported it, userinfo included.
+	// Only set for the credential category; URL has the userinfo stripped.
+	LeakedURL string `json:"leaked_url,omitempty"`
+	// CapturedAt is when the source saw the URL (zero if it did not say).
+	CapturedAt time.Time `json:"captured_at,omitzero"`
+
+	// Filled in at the dedup gate from every record seen for this URL.
+	Sources   []string  `json:"sources,omitempty"`
+	FirstSeen time.Time `json:"first_seen,omitzero"`
+	LastSeen  time.Time `json:"last_seen,omitzero"`
+	// New marks URLs that no earlier scan of this output directory saw.
+	New bool `json:"new,omitempty"`
+
+	// Filled in by the status gate; Title needs --probe.
+	Title         string     `json:"title,omitempty"`
+	ContentLength int64      `json:"content_length,omitempty"`
+	ContentType   string     `json:"content_type,omitempty"`
+	Server        string     `json:"server,omitempty"`
+	FinalURL      string     `json:"final_url,omitempty"`
+	RedirectChain []Redirect `json:"redirect_chain,omitempty"`
+}
+
+// Redirect is one hop followed while probing a URL.
+type Redirect struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status"`
 }
 
 // New creates a new AppContext.
@@ -0,0 +1,219 @@
+package dedup
+
+import (
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+const (
+	// Capacity of the first filter layer; later layers double it.
+	bloomInitialCapacity = 1 << 20
+	// Each new layer halves its false-positive target so the compound rate
+	// stays under the configured one (p/2 + p/4 + ... < p).
+	bloomTightening = 0.5
+	// Layers smaller than this are not worth adding once near the ceiling.
+	bloomMinLayerBytes = 1 << 20
+)
+
+// Bloom is a scalable Bloom filter: a stack of fixed-size layers, each
+// added when the previous one is full, until the memory ceiling is hit.
+// It never forgets a URL, but may wrongly report an unseen one as seen.
+type Bloom struct {
+	mu        sync.Mutex
+	layers    []*bloomLayer
+	maxBytes  uint64
+	bytes     uint64
+	saturated bool
+
+	seed1 maphash.Seed
+	seed2 maphash.Seed
+}
+
+type bloomLayer struct {
+	bits     []uint64
+	m        uint64 // number of bits
+	k        uint64 // hash functions
+	capacity uint64
+	count    uint64
+	fp       float64
+}
+
+// NewBloom creates a filter targeting fpRate that never allocates more
+// than maxBytes. Zero values fall back to 0.1% and 256 MB.
+func NewBloom(fpRate float64, maxBytes uint64) *Bloom {
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.001
+	}
+	if maxBytes == 0 {
+		maxBytes = 256 << 20
+	}
+
+	b := &Bloom{
+		maxBytes: maxBytes,
+		seed1:    maphash.MakeSeed(),
+		seed2:    maphash.MakeSeed(),
+	}
+
+	fp := fpRate * (1 - bloomTightening)
+	if m := bloomBits(bloomInitialCapacity, fp); m/8 <= maxBytes {
+		b.push(makeBloomLayer(m, bloomInitialCapacity, fp))
+	} else {
+		b.push(bloomLayerForBytes(maxBytes, fp))
+	}
+	return b
+}
+
+// Has reports whether key was (probably) added before, without adding it.
+func (b *Bloom) Has(key string) bool {
+	h1, h2 := b.hash(key)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.has(h1, h2)
+}
+
+// Add records key and reports whether it was (probably) seen before.
+func (b *Bloom) Add(key string) bool {
+	h1, h2 := b.hash(key)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.has(h1, h2) {
+		return true
+	}
+
+	cur := b.layers[len(b.layers)-1]
+	if cur.count >= cur.capacity && !b.saturated {
+		cur = b.grow(cur)
+	}
+	cur.set(h1, h2)
+	cur.count++
+	return false
+}
+
+func (b *Bloom) hash(key string) (uint64, uint64) {
+	h1 := maphash.String(b.seed1, key)
+	h2 := maphash.String(b.seed2, key) | 1 // odd, so probes cover the whole table
+	return h1, h2
+}
+
+func (b *Bloom) has(h1, h2 uint64) bool {
+	for _, l := range b.layers {
+		if l.has(h1, h2) {
+			return true
+		}
+	}
+	return false
+}
+
+// grow adds the next layer, or marks the filter saturated when the memory
+// ceiling leaves no room. A saturated filter keeps filling its last layer,
+// so its false-positive rate climbs instead of its memory.
+func (b *Bloom) grow(cur *bloomLayer) *bloomLayer {
+	capacity, fp := cur.capacity*2, cur.fp*bloomTightening
+
+	var next *bloomLayer
+	if m := bloomBits(capacity, fp); b.bytes+m/8 <= b.maxBytes {
+		next = makeBloomLayer(m, capacity, fp)
+	} else {
+		remaining := b.maxBytes - b.bytes
+		if remaining < bloomMinLayerBytes {
+			b.saturated = true
+			return cur
+		}
+		next = bloomLayerForBytes(remaining, fp)
+	}
+	b.push(next)
+	return next
+}
+
+func (b *Bloom) push(l *bloomLayer) {
+	b.layers = append(b.layers, l)
+	b.bytes += l.bytes()
+}
+
+// MemoryBytes returns the memory held by the filter's bit arrays.
+func (b *Bloom) MemoryBytes() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bytes
+}
+
+// EstimatedFPRate returns the current false-positive probability given
+// how full each layer is.
+func (b *Bloom) EstimatedFPRate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	miss := 1.0
+	for _, l := range b.layers {
+		fill := 1 - math.Exp(-float64(l.k)*float64(l.count)/float64(l.m))
+		miss *= 1 - math.Pow(fill, float64(l.k))
+	}
+	return 1 - miss
+}
+
+// Saturated reports whether the memory ceiling stopped the filter growing.
+func (b *Bloom) Saturated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.saturated
+}
+
+// bloomBits is the optimal bit count for capacity items at rate fp.
+func bloomBits(capacity uint64, fp float64) uint64 {
+	return uint64(math.Ceil(-float64(capacity) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+}
+
+// bloomLayerForBytes sizes a layer to fit in bytes and derives its capacity.
+func bloomLayerForBytes(bytes uint64, fp float64) *bloomLayer {
+	m := (bytes * 8) &^ 63 // stay under the ceiling
+	capacity := uint64(float64(m) * math.Ln2 * math.Ln2 / -math.Log(fp))
+	if capacity < 1 {
+		capacity = 1
+	}
+	return makeBloomLayer(m, capacity, fp)
+}
+
+func makeBloomLayer(m, capacity uint64, fp float64) *bloomLayer {
+	m = (m + 63) &^ 63 // whole words
+	if m == 0 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomLayer{
+		bits:     make([]uint64, m/64),
+		m:        m,
+		k:        k,
+		capacity: capacity,
+		fp:       fp,
+	}
+}
+
+func (l *bloomLayer) bytes() uint64 {
+	return l.m / 8
+}
+
+// Double hashing: probe i lands on h1 + i*h2 (Kirsch-Mitzenmacher).
+func (l *bloomLayer) has(h1, h2 uint64) bool {
+	for i := uint64(0); i < l.k; i++ {
+		bit := (h1 + i*h2) % l.m
+		if l.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *bloomLayer) set(h1, h2 uint64) {
+	for i := uint64(0); i < l.k; i++ {
+		bit := (h1 + i*h2) % l.m
+		l.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
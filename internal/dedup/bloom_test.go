@@ -0,0 +1,50 @@
+package dedup
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomNoFalseNegatives(t *testing.T) {
+	b := NewBloom(0.01, 4<<20)
+
+	for i := 0; i < 50000; i++ {
+		b.Add(fmt.Sprintf("https://example.com/%d", i))
+	}
+	for i := 0; i < 50000; i++ {
+		if !b.Add(fmt.Sprintf("https://example.com/%d", i)) {
+			t.Fatalf("URL %d was forgotten", i)
+		}
+	}
+}
+
+func TestBloomFalsePositiveRate(t *testing.T) {
+	b := NewBloom(0.01, 4<<20)
+	for i := 0; i < 100000; i++ {
+		b.Add(fmt.Sprintf("https://example.com/seen/%d", i))
+	}
+
+	fp := 0
+	for i := 0; i < 100000; i++ {
+		if b.Add(fmt.Sprintf("https://example.com/unseen/%d", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 100000; rate > 0.02 {
+		t.Errorf("False-positive rate %.4f is well above the 1%% target", rate)
+	}
+}
+
+func TestBloomMemoryCeiling(t *testing.T) {
+	b := NewBloom(0.01, 2<<20)
+	for i := 0; i < 3000000; i++ {
+		b.Add(fmt.Sprintf("u%d", i))
+	}
+
+	if b.MemoryBytes() > 2<<20 {
+		t.Errorf("Expected at most 2 MB, got %d bytes", b.MemoryBytes())
+	}
+	if !b.Saturated() {
+		t.Error("Expected the filter to report saturation")
+	}
+}
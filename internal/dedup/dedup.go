@@ -2,8 +2,13 @@ package dedup
 
 import (
 	"net/url"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/scope"
 )
 
 // CheckResult indicates if a URL should proceed.
@@ -14,73 +19,419 @@ const (
 	Drop CheckResult = false
 )
 
+// Sighting is everything known about a unique URL: which sources reported
+// it and the earliest/latest capture dates they provided.
+type Sighting struct {
+	Sources   []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// New is set when a history Store is in use and no earlier scan
+	// output the URL.
+	New bool
+}
+
+// sighting is the mutable, shared form stored per URL.
+type sighting struct {
+	mu sync.Mutex
+	Sighting
+
+	written string // URL as output, set by Remember
+}
+
+func (s *sighting) snapshot() Sighting {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := s.Sighting
+	out.Sources = append([]string(nil), s.Sources...)
+	return out
+}
+
+func (s *sighting) add(source string, seenAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if source != "" {
+		i := sort.SearchStrings(s.Sources, source)
+		if i == len(s.Sources) || s.Sources[i] != source {
+			s.Sources = append(s.Sources, "")
+			copy(s.Sources[i+1:], s.Sources[i:])
+			s.Sources[i] = source
+		}
+	}
+
+	if !seenAt.IsZero() {
+		if s.FirstSeen.IsZero() || seenAt.Before(s.FirstSeen) {
+			s.FirstSeen = seenAt
+		}
+		if seenAt.After(s.LastSeen) {
+			s.LastSeen = seenAt
+		}
+	}
+}
+
 // Dedup handles duplication checking and scope enforcement.
 type Dedup struct {
-	seen         sync.Map
+	seen         sync.Map // normalized URL -> *sighting
 	targetDomain string
 	wildcard     bool
 	disableDedup bool
+
+	store    *Store
+	newOnly  bool
+	newCount uint64
+	oldCount uint64
+
+	bloom      *Bloom
+	exactBytes uint64 // rough size of the exact map, for the summary
+
+	templates *Templates
+
+	paramKey    bool
+	ignoreOrder bool
+
+	scope    *scope.Scope
+	scopeLog *scope.Log
+}
+
+// exactEntryOverhead approximates what a sync.Map entry and its sighting
+// cost on top of the URL string itself.
+const exactEntryOverhead = 160
+
+// Memory describes the dedup backend and its estimated footprint.
+type Memory struct {
+	Mode      string // "exact" or "bloom"
+	Bytes     uint64
+	FPRate    float64 // bloom only
+	Saturated bool    // bloom hit its memory ceiling
+}
+
+// Option tunes a Dedup beyond its scope settings.
+type Option func(*Dedup)
+
+// WithStore checks every unique URL against a cross-run history; Remember
+// adds the ones that reach the output. With newOnly, URLs an earlier scan
+// already output are dropped.
+func WithStore(s *Store, newOnly bool) Option {
+	return func(d *Dedup) {
+		d.store = s
+		d.newOnly = newOnly
+	}
+}
+
+// WithBloom replaces the exact map with a Bloom filter bounded by maxBytes.
+// Memory stays flat on huge targets at the cost of dropping roughly fpRate
+// of unseen URLs as duplicates, and of later sources no longer adding to a
+// URL's Sighting. Pair it with OpenBloomStore and a templates cap to bound
+// the rest.
+func WithBloom(fpRate float64, maxBytes uint64) Option {
+	return func(d *Dedup) {
+		d.bloom = NewBloom(fpRate, maxBytes)
+	}
+}
+
+// WithTemplates additionally collapses URLs that share a structural
+// template, letting through only the first samples of each. maxBytes caps
+// the template table (0 = no cap).
+func WithTemplates(samples int, maxBytes uint64) Option {
+	return func(d *Dedup) {
+		d.templates = NewTemplates(samples, maxBytes)
+	}
+}
+
+// WithParamKey dedups on scheme, host, path and the set of query parameter
+// names, so ?id=1 and ?id=2 count as the same URL. With ignoreOrder,
+// ?a=1&b=2 and ?b=2&a=1 do too.
+func WithParamKey(ignoreOrder bool) Option {
+	return func(d *Dedup) {
+		d.paramKey = true
+		d.ignoreOrder = ignoreOrder
+	}
+}
+
+// WithScope applies a program's scope file on top of (or, if it has include
+// rules, instead of) the target domain. Dropped URLs are recorded in log,
+// which may be nil; sc may be nil to only log target mismatches.
+func WithScope(sc *scope.Scope, log *scope.Log) Option {
+	return func(d *Dedup) {
+		d.scope = sc
+		d.scopeLog = log
+	}
 }
 
 // New creates a new deduplicator.
-func New(targetDomain string, wildcard bool, disableDedup bool) *Dedup {
-	return &Dedup{
+func New(targetDomain string, wildcard bool, disableDedup bool, opts ...Option) *Dedup {
+	d := &Dedup{
 		targetDomain: strings.ToLower(targetDomain),
 		wildcard:     wildcard,
 		disableDedup: disableDedup,
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 // Check returns Pass if the URL is valid and unseen, Drop otherwise.
 func (d *Dedup) Check(rawURL string) CheckResult {
+	result, _ := d.Observe(rawURL, "", time.Time{})
+	return result
+}
+
+// Observe is Check that also remembers the reporting source and capture
+// time, so duplicates still add to the URL's Sighting. On Pass it returns
+// what is known about the URL so far.
+func (d *Dedup) Observe(rawURL, source string, seenAt time.Time) (CheckResult, Sighting) {
 	// 1. Scope Check (Wildcard Logic)
 	// We parse again here because we need the Host.
 	// (Optimization: Pass cached parsed URL if possible later)
 	u, err := url.Parse(rawURL)
 	if err != nil {
-		return Drop
+		return Drop, Sighting{}
 	}
 
-	if !d.inScope(u.Host) {
-		return Drop
+	if in, rule := d.inScope(u); !in {
+		d.scopeLog.Record(rawURL, rule)
+		return Drop, Sighting{}
 	}
 
 	// 2. Dedup Check
 	if d.disableDedup {
-		return Pass
+		return Pass, Sighting{}
+	}
+
+	key := d.key(rawURL)
+
+	if d.bloom != nil {
+		if d.bloom.Add(key) {
+			return Drop, Sighting{}
+		}
+		// Nothing is kept per URL, so the first sighting is all there is
+		s := &sighting{}
+		s.add(source, seenAt)
+		if d.store != nil && !d.isNew(s, key) && d.newOnly {
+			return Drop, Sighting{}
+		}
+		return d.checkTemplate(rawURL), s.Sighting
 	}
 
-	// We use the full rawURL as the key.
-	// Since it's already normalized by the pipeline, this is safe.
-	if _, loaded := d.seen.LoadOrStore(rawURL, true); loaded {
+	// The URL is already normalized by the pipeline, so it is safe to key on.
+	entry, loaded := d.seen.Load(key)
+	if !loaded {
+		entry, loaded = d.seen.LoadOrStore(key, &sighting{})
+		if !loaded {
+			atomic.AddUint64(&d.exactBytes, uint64(len(key))+exactEntryOverhead)
+		}
+	}
+	s := entry.(*sighting)
+	s.add(source, seenAt)
+	if loaded {
 		// Already seen
-		return Drop
+		return Drop, Sighting{}
 	}
 
-	return Pass
+	if d.store != nil {
+		if !d.isNew(s, key) && d.newOnly {
+			return Drop, Sighting{}
+		}
+	}
+
+	return d.checkTemplate(rawURL), s.snapshot()
 }
 
-// inScope checks if the host matches the target rules.
-func (d *Dedup) inScope(host string) bool {
-	host = strings.ToLower(host)
+// checkTemplate drops a unique URL once its template has enough samples.
+// It runs last, so URLs already dropped don't use up a template's samples.
+func (d *Dedup) checkTemplate(rawURL string) CheckResult {
+	if d.templates == nil || d.templates.Add(rawURL) {
+		return Pass
+	}
+	return Drop
+}
+
+// isNew checks a unique URL against the history. It only reads: URLs are
+// added by Remember once they are written, so ones dropped by the status
+// gate or the filters can still turn up as new in a later scan.
+func (d *Dedup) isNew(s *sighting, key string) bool {
+	isNew := !d.store.Has(key)
+	if !isNew {
+		atomic.AddUint64(&d.oldCount, 1)
+	}
+
+	s.mu.Lock()
+	s.New = isNew
+	s.mu.Unlock()
+	return isNew
+}
+
+// Remember notes that a URL reached the output, for Sightings, and adds it
+// to the history, counting it as new if no earlier scan had output it.
+func (d *Dedup) Remember(rawURL string) {
+	if d.disableDedup {
+		return
+	}
+	key := d.key(rawURL)
+
+	if entry, ok := d.seen.Load(key); ok {
+		s := entry.(*sighting)
+		s.mu.Lock()
+		s.written = rawURL
+		s.mu.Unlock()
+	}
+
+	if d.store != nil && d.store.Add(key) {
+		atomic.AddUint64(&d.newCount, 1)
+	}
+}
+
+// WrittenSighting pairs a URL that reached the output with its Sighting.
+type WrittenSighting struct {
+	URL string
+	Sighting
+}
+
+// Sightings returns the final sightings of every URL passed to Remember,
+// in no particular order. The Bloom backend keeps none.
+func (d *Dedup) Sightings() []WrittenSighting {
+	var out []WrittenSighting
+	d.seen.Range(func(_, entry any) bool {
+		s := entry.(*sighting)
+		s.mu.Lock()
+		written := s.written
+		s.mu.Unlock()
+		if written != "" {
+			out = append(out, WrittenSighting{URL: written, Sighting: s.snapshot()})
+		}
+		return true
+	})
+	return out
+}
+
+// Memory reports the backend in use and the estimated memory of everything
+// dedup holds: the filter or map, the loaded history and the templates.
+func (d *Dedup) Memory() Memory {
+	var extra uint64
+	if d.store != nil {
+		extra += d.store.MemoryBytes()
+	}
+	if d.templates != nil {
+		extra += d.templates.MemoryBytes()
+	}
+
+	if d.bloom != nil {
+		return Memory{
+			Mode:      "bloom",
+			Bytes:     d.bloom.MemoryBytes() + extra,
+			FPRate:    d.bloom.EstimatedFPRate(),
+			Saturated: d.bloom.Saturated(),
+		}
+	}
+	return Memory{Mode: "exact", Bytes: atomic.LoadUint64(&d.exactBytes) + extra}
+}
+
+// Templates returns the structural templates seen so far, most common
+// first, and how many URLs were collapsed. It is nil without WithTemplates.
+func (d *Dedup) Templates() ([]TemplateCount, uint64) {
+	if d.templates == nil {
+		return nil, 0
+	}
+	return d.templates.Counts(), d.templates.Collapsed()
+}
+
+// HistoryCounts returns how many output URLs were new to the history and
+// how many unique URLs earlier scans had already output.
+func (d *Dedup) HistoryCounts() (newURLs, known uint64) {
+	return atomic.LoadUint64(&d.newCount), atomic.LoadUint64(&d.oldCount)
+}
+
+// Sighting returns a snapshot of what is known about a URL so far.
+// The Bloom backend keeps no sightings.
+func (d *Dedup) Sighting(rawURL string) (Sighting, bool) {
+	entry, ok := d.seen.Load(d.key(rawURL))
+	if !ok {
+		return Sighting{}, false
+	}
+
+	return entry.(*sighting).snapshot(), true
+}
+
+// key is what URLs are deduplicated on.
+func (d *Dedup) key(rawURL string) string {
+	if !d.paramKey {
+		return rawURL
+	}
+	return ParamKey(rawURL, d.ignoreOrder)
+}
+
+// ParamKey reduces a URL to its scheme, host, path and query parameter
+// names, e.g. https://example.com/search?q&page. Names appear once each, in
+// the order first seen unless ignoreOrder sorts them.
+func ParamKey(rawURL string, ignoreOrder bool) string {
+	base, query, _ := strings.Cut(rawURL, "?")
+	if query == "" {
+		return base
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, pair := range strings.Split(query, "&") {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if ignoreOrder {
+		sort.Strings(names)
+	}
+	return base + "?" + strings.Join(names, "&")
+}
+
+// InScope reports whether u is inside the target and the scope file, by
+// the same rules Observe applies.
+func (d *Dedup) InScope(u *url.URL) bool {
+	in, _ := d.inScope(u)
+	return in
+}
+
+// inScope checks if the URL matches the target rules and, when it does
+// not, returns the rule responsible.
+func (d *Dedup) inScope(u *url.URL) (bool, string) {
+	if d.scope != nil {
+		if rule, excluded := d.scope.Excluded(u); excluded {
+			return false, rule
+		}
+		if d.scope.HasIncludes() {
+			if d.scope.Included(u) {
+				return true, ""
+			}
+			return false, "no include rule"
+		}
+	}
+
+	host := strings.ToLower(u.Hostname())
 
 	// If no domain was given (e.g. input file mode without domain constraint), pass everything?
 	// Architecture says "REQUIRED: -d". So we assume strict mode.
 	if d.targetDomain == "" {
-		return true
+		return true, ""
 	}
 
 	if host == d.targetDomain {
-		return true
+		return true, ""
 	}
 
 	if d.wildcard {
 		// Check for .targetDomain suffix
 		suffix := "." + d.targetDomain
 		if strings.HasSuffix(host, suffix) {
-			return true
+			return true, ""
 		}
+		return false, "outside *." + d.targetDomain
 	}
 
-	return false
+	return false, "outside " + d.targetDomain
 }
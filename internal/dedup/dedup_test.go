@@ -0,0 +1,225 @@
+package dedup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/scope"
+)
+
+func TestObserveTracksSightings(t *testing.T) {
+	d := New("example.com", false, false)
+	u := "https://example.com/a"
+
+	jan := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	jun := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	if result, seen := d.Observe(u, "wayback", jun); result != Pass || !reflect.DeepEqual(seen.Sources, []string{"wayback"}) {
+		t.Fatalf("Expected first sighting to pass with its source, got %v %+v", result, seen)
+	}
+	if result, _ := d.Observe(u, "otx", time.Time{}); result != Drop {
+		t.Fatal("Expected duplicate to drop")
+	}
+	d.Observe(u, "wayback", jan)
+
+	if len(d.Sightings()) != 0 {
+		t.Error("Expected no sightings before the URL is written")
+	}
+	d.Remember(u)
+	sightings := d.Sightings()
+	if len(sightings) != 1 || sightings[0].URL != u {
+		t.Fatalf("Expected one written sighting, got %+v", sightings)
+	}
+	seen := sightings[0]
+	if want := []string{"otx", "wayback"}; !reflect.DeepEqual(seen.Sources, want) {
+		t.Errorf("Expected sources %v, got %v", want, seen.Sources)
+	}
+	if !seen.FirstSeen.Equal(jan) || !seen.LastSeen.Equal(jun) {
+		t.Errorf("Expected %v..%v, got %v..%v", jan, jun, seen.FirstSeen, seen.LastSeen)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	d := New("example.com", true, false)
+
+	if d.Check("https://api.example.com/") != Pass {
+		t.Error("Expected subdomain to pass with wildcard")
+	}
+	if d.Check("https://example.org/") != Drop {
+		t.Error("Expected out-of-scope host to drop")
+	}
+}
+
+func TestStoreNewOnlyAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.txt")
+
+	run := func(urls ...string) []string {
+		store, err := OpenStore(path)
+		if err != nil {
+			t.Fatalf("OpenStore: %v", err)
+		}
+		defer store.Close()
+
+		d := New("example.com", false, false, WithStore(store, true))
+		var passed []string
+		for _, u := range urls {
+			if d.Check(u) == Pass {
+				passed = append(passed, u)
+				d.Remember(u)
+			}
+		}
+		return passed
+	}
+
+	first := run("https://example.com/a", "https://example.com/b")
+	if len(first) != 2 {
+		t.Fatalf("Expected both URLs on the first run, got %v", first)
+	}
+
+	second := run("https://example.com/a", "https://example.com/c", "https://example.com/c")
+	if want := []string{"https://example.com/c"}; !reflect.DeepEqual(second, want) {
+		t.Errorf("Expected only %v on the second run, got %v", want, second)
+	}
+}
+
+func TestStoreOnlyRemembersOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.txt")
+
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	d := New("example.com", false, false, WithStore(store, false))
+	d.Check("https://example.com/written")
+	d.Remember("https://example.com/written")
+	d.Check("https://example.com/dead") // dropped by the status gate
+	if n, known := d.HistoryCounts(); n != 1 || known != 0 {
+		t.Errorf("Expected 1 new and 0 known, got %d and %d", n, known)
+	}
+	store.Close()
+
+	store, err = OpenStore(path)
+	if err != nil {
+		t.Fatalf("OpenStore: %v", err)
+	}
+	defer store.Close()
+	d = New("example.com", false, false, WithStore(store, false))
+	for _, u := range []string{"https://example.com/written", "https://example.com/dead"} {
+		_, seen := d.Observe(u, "", time.Time{})
+		if want := u == "https://example.com/dead"; seen.New != want {
+			t.Errorf("%s: expected new=%v, got %v", u, want, seen.New)
+		}
+	}
+}
+
+func TestBloomModeWithHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.txt")
+	os.WriteFile(path, []byte("https://example.com/old\n"), 0644)
+
+	store, err := OpenBloomStore(path, 0.001, 1<<20)
+	if err != nil {
+		t.Fatalf("OpenBloomStore: %v", err)
+	}
+	defer store.Close()
+
+	d := New("example.com", false, false, WithBloom(0.001, 1<<20), WithStore(store, false))
+	for _, u := range []string{"https://example.com/old", "https://example.com/fresh"} {
+		result, seen := d.Observe(u, "wayback", time.Time{})
+		if result != Pass {
+			t.Fatalf("%s: expected pass", u)
+		}
+		if want := u == "https://example.com/fresh"; seen.New != want {
+			t.Errorf("%s: expected new=%v, got %v", u, want, seen.New)
+		}
+		if !reflect.DeepEqual(seen.Sources, []string{"wayback"}) {
+			t.Errorf("%s: expected the reporting source, got %v", u, seen.Sources)
+		}
+		d.Remember(u)
+	}
+
+	if n, known := d.HistoryCounts(); n != 1 || known != 1 {
+		t.Errorf("Expected 1 new and 1 known, got %d and %d", n, known)
+	}
+	if mem := d.Memory(); mem.Bytes < 2<<20 {
+		t.Errorf("Expected memory to include the history filter, got %d bytes", mem.Bytes)
+	}
+}
+
+func TestParamKey(t *testing.T) {
+	tests := []struct {
+		url         string
+		ignoreOrder bool
+		want        string
+	}{
+		{"https://example.com/item?id=1", false, "https://example.com/item?id"},
+		{"https://example.com/item?b=2&a=1&b=3", false, "https://example.com/item?b&a"},
+		{"https://example.com/item?b=2&a=1", true, "https://example.com/item?a&b"},
+		{"https://example.com/item", false, "https://example.com/item"},
+	}
+
+	for _, tt := range tests {
+		if got := ParamKey(tt.url, tt.ignoreOrder); got != tt.want {
+			t.Errorf("ParamKey(%q, %v) = %q, want %q", tt.url, tt.ignoreOrder, got, tt.want)
+		}
+	}
+}
+
+func TestObserveParamKey(t *testing.T) {
+	d := New("example.com", false, false, WithParamKey(false))
+
+	if result, _ := d.Observe("https://example.com/item?id=1", "wayback", time.Time{}); result != Pass {
+		t.Fatal("Expected first parameter set to pass")
+	}
+	if result, _ := d.Observe("https://example.com/item?id=2", "otx", time.Time{}); result != Drop {
+		t.Error("Expected a different value for the same parameter to drop")
+	}
+	if d.Check("https://example.com/item?id=2&page=1") != Pass {
+		t.Error("Expected a new parameter set to pass")
+	}
+	if d.Check("https://example.com/item?page=1&id=2") != Pass {
+		t.Error("Expected a different parameter order to pass when order matters")
+	}
+
+	seen, ok := d.Sighting("https://example.com/item?id=1")
+	if !ok || len(seen.Sources) != 2 {
+		t.Errorf("Expected sightings to merge across values, got %+v", seen)
+	}
+}
+
+func TestScopeExcludesAndLogs(t *testing.T) {
+	dir := t.TempDir()
+	scopePath := filepath.Join(dir, "scope.txt")
+	os.WriteFile(scopePath, []byte("!blog.example.com\n"), 0644)
+
+	sc, err := scope.Load(scopePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "out_of_scope.txt")
+	log, err := scope.OpenLog(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Exclude-only scope files still honour -d/--wildcard
+	d := New("example.com", true, false, WithScope(sc, log))
+	if d.Check("https://api.example.com/") != Pass {
+		t.Error("Expected subdomain to pass")
+	}
+	if d.Check("https://blog.example.com/post") != Drop {
+		t.Error("Expected excluded host to drop")
+	}
+	if d.Check("https://example.org/") != Drop {
+		t.Error("Expected host outside the target to drop")
+	}
+	log.Close()
+
+	data, _ := os.ReadFile(logPath)
+	want := "https://blog.example.com/post\t!blog.example.com\nhttps://example.org/\toutside *.example.com\n"
+	if string(data) != want {
+		t.Errorf("Unexpected log:\n%s", data)
+	}
+}
@@ -0,0 +1,132 @@
+package dedup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// storeEntryOverhead approximates what a map entry costs on top of the URL.
+const storeEntryOverhead = 48
+
+// Store remembers every URL output by previous scans of a target. It is a
+// plain append-only file with one normalized URL per line, so it can be
+// inspected, grepped or deleted to start over.
+type Store struct {
+	mu     sync.Mutex
+	known  map[string]struct{}
+	filter *Bloom // replaces known for OpenBloomStore
+	bytes  uint64 // rough size of known, for the summary
+	file   *os.File
+	writer *bufio.Writer
+	loaded int
+}
+
+// OpenStore loads the history at path into memory, creating the file if
+// needed.
+func OpenStore(path string) (*Store, error) {
+	return openStore(path, &Store{known: make(map[string]struct{})})
+}
+
+// OpenBloomStore loads the history at path into a Bloom filter bounded by
+// maxBytes, so a long history does not undo --dedup-mode bloom. Roughly
+// fpRate of never-seen URLs are taken for known ones.
+func OpenBloomStore(path string, fpRate float64, maxBytes uint64) (*Store, error) {
+	return openStore(path, &Store{filter: NewBloom(fpRate, maxBytes)})
+}
+
+func openStore(path string, s *Store) (*Store, error) {
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && s.add(line) {
+				s.loaded++
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to open history %s: %w", path, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history %s: %w", path, err)
+	}
+	s.file = f
+	s.writer = bufio.NewWriter(f)
+
+	return s, nil
+}
+
+// Loaded returns how many URLs previous scans had recorded.
+func (s *Store) Loaded() int {
+	return s.loaded
+}
+
+// Has reports whether an earlier scan (or this one) recorded the URL.
+func (s *Store) Has(u string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.filter != nil {
+		return s.filter.Has(u)
+	}
+	_, ok := s.known[u]
+	return ok
+}
+
+// Add records a URL and reports whether it was never seen before.
+func (s *Store) Add(u string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.add(u) {
+		return false
+	}
+	s.writer.WriteString(u + "\n")
+	return true
+}
+
+// add records u in memory and reports whether it is new. Called with mu
+// held (or before the Store is shared).
+func (s *Store) add(u string) bool {
+	if s.filter != nil {
+		return !s.filter.Add(u)
+	}
+	if _, ok := s.known[u]; ok {
+		return false
+	}
+	s.known[u] = struct{}{}
+	s.bytes += uint64(len(u)) + storeEntryOverhead
+	return true
+}
+
+// MemoryBytes estimates the memory held by the loaded history.
+func (s *Store) MemoryBytes() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.filter != nil {
+		return s.filter.MemoryBytes()
+	}
+	return s.bytes
+}
+
+// Close flushes new entries to disk.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Flush(); err != nil {
+		s.file.Close()
+		return err
+	}
+	return s.file.Close()
+}
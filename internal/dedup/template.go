@@ -0,0 +1,175 @@
+package dedup
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholders used in URL templates.
+const (
+	shapeInt  = "{int}"
+	shapeUUID = "{uuid}"
+	shapeHash = "{hash}"
+	shapeDate = "{date}"
+)
+
+var (
+	uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	dateRe = regexp.MustCompile(`^(19|20)\d{2}-?(0[1-9]|1[0-2])-?(0[1-9]|[12]\d|3[01])$`)
+	hexRe  = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	digits = regexp.MustCompile(`^\d+$`)
+	// Extensions kept on templated segments, so /p/123.html and /p/123.json stay apart.
+	extRe = regexp.MustCompile(`^[A-Za-z0-9]{1,5}$`)
+)
+
+// Templates groups URLs by shape and keeps only the first few of each, so
+// millions of /product/<id> variants don't bury the interesting endpoints.
+type Templates struct {
+	mu        sync.Mutex
+	samples   int
+	counts    map[string]uint64
+	collapsed uint64
+
+	maxBytes uint64 // 0 = no cap
+	bytes    uint64
+}
+
+// templateEntryOverhead approximates what a map entry costs on top of the
+// template string itself.
+const templateEntryOverhead = 56
+
+// TemplateCount is a URL template and how many unique URLs matched it.
+type TemplateCount struct {
+	Template string
+	Count    uint64
+}
+
+// NewTemplates keeps up to samples URLs per template (at least one). With
+// maxBytes set, templates stop being tracked once the table reaches it.
+func NewTemplates(samples int, maxBytes uint64) *Templates {
+	if samples < 1 {
+		samples = 1
+	}
+	return &Templates{
+		samples:  samples,
+		counts:   make(map[string]uint64),
+		maxBytes: maxBytes,
+	}
+}
+
+// Add counts rawURL under its template and reports whether it is still
+// within the sample budget. Once the table is full, URLs with a template
+// not seen before pass uncounted.
+func (t *Templates) Add(rawURL string) bool {
+	key := Template(rawURL)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.counts[key]; !ok {
+		size := uint64(len(key)) + templateEntryOverhead
+		if t.maxBytes > 0 && t.bytes+size > t.maxBytes {
+			return true
+		}
+		t.bytes += size
+	}
+
+	t.counts[key]++
+	if t.counts[key] > uint64(t.samples) {
+		t.collapsed++
+		return false
+	}
+	return true
+}
+
+// MemoryBytes estimates the memory held by the template table.
+func (t *Templates) MemoryBytes() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.bytes
+}
+
+// Collapsed returns how many URLs were dropped for exceeding the budget.
+func (t *Templates) Collapsed() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.collapsed
+}
+
+// Counts returns every template, most common first.
+func (t *Templates) Counts() []TemplateCount {
+	t.mu.Lock()
+	out := make([]TemplateCount, 0, len(t.counts))
+	for tmpl, n := range t.counts {
+		out = append(out, TemplateCount{Template: tmpl, Count: n})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Template < out[j].Template
+	})
+	return out
+}
+
+// Template reduces a URL to its shape: numeric, UUID, hash and date path
+// segments become placeholders and the query keeps only its parameter
+// names, e.g. https://shop.example.com/product/{int}?ref=
+func Template(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segments {
+		segments[i] = segmentShape(seg)
+	}
+
+	var b strings.Builder
+	b.WriteString(u.Scheme)
+	b.WriteString("://")
+	b.WriteString(u.Host)
+	b.WriteString(strings.Join(segments, "/"))
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		names := make([]string, 0, len(query))
+		for name := range query {
+			names = append(names, name+"=")
+		}
+		sort.Strings(names)
+		b.WriteString("?")
+		b.WriteString(strings.Join(names, "&"))
+	}
+	return b.String()
+}
+
+// segmentShape replaces a path segment that looks like an identifier.
+func segmentShape(seg string) string {
+	if seg == "" {
+		return seg
+	}
+
+	stem, ext := seg, ""
+	if i := strings.LastIndexByte(seg, '.'); i > 0 && extRe.MatchString(seg[i+1:]) {
+		stem, ext = seg[:i], seg[i:]
+	}
+
+	switch {
+	case uuidRe.MatchString(stem):
+		return shapeUUID + ext
+	case dateRe.MatchString(stem):
+		return shapeDate + ext
+	case digits.MatchString(stem):
+		return shapeInt + ext
+	case hexRe.MatchString(stem) && strings.ContainsAny(stem, "0123456789"):
+		return shapeHash + ext
+	}
+	return seg
+}
@@ -0,0 +1,68 @@
+package dedup
+
+import "testing"
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://shop.example.com/users/123/profile", "https://shop.example.com/users/{int}/profile"},
+		{"https://example.com/order/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "https://example.com/order/{uuid}"},
+		{"https://example.com/static/d41d8cd98f00b204e9800998ecf8427e.js", "https://example.com/static/{hash}.js"},
+		{"https://example.com/news/2023-01-15/story", "https://example.com/news/{date}/story"},
+		{"https://example.com/p/42.html?ref=abc&id=7", "https://example.com/p/{int}.html?id=&ref="},
+		{"https://example.com/about/team", "https://example.com/about/team"},
+	}
+
+	for _, tt := range tests {
+		if got := Template(tt.url); got != tt.want {
+			t.Errorf("Template(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestObserveKeepsTemplateSamples(t *testing.T) {
+	d := New("example.com", false, false, WithTemplates(2, 0))
+
+	passed := 0
+	for _, u := range []string{
+		"https://example.com/product/1",
+		"https://example.com/product/2",
+		"https://example.com/product/3",
+		"https://example.com/product/3", // duplicate, must not count twice
+		"https://example.com/login",
+	} {
+		if d.Check(u) == Pass {
+			passed++
+		}
+	}
+	if passed != 3 {
+		t.Errorf("Expected 3 URLs to pass, got %d", passed)
+	}
+
+	templates, collapsed := d.Templates()
+	if collapsed != 1 {
+		t.Errorf("Expected 1 collapsed URL, got %d", collapsed)
+	}
+	if len(templates) != 2 || templates[0].Template != "https://example.com/product/{int}" || templates[0].Count != 3 {
+		t.Errorf("Unexpected templates: %+v", templates)
+	}
+}
+
+func TestTemplatesMemoryCap(t *testing.T) {
+	first := "https://example.com/product/1"
+	tpl := NewTemplates(1, uint64(len(Template(first)))+templateEntryOverhead)
+
+	if !tpl.Add(first) || tpl.Add("https://example.com/product/2") {
+		t.Error("Expected the tracked template to keep collapsing")
+	}
+	for _, u := range []string{"https://example.com/user/1", "https://example.com/user/2"} {
+		if !tpl.Add(u) {
+			t.Errorf("%s: expected untracked templates to pass once full", u)
+		}
+	}
+	if n := len(tpl.Counts()); n != 1 {
+		t.Errorf("Expected 1 tracked template, got %d", n)
+	}
+}
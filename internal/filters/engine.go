@@ -24,6 +24,10 @@ const (
 	CatJS       = "js"
 	CatParam    = "param"
 	CatNone     = "none"
+
+	// CatCredential marks URLs that carried user:pass@ userinfo. It is set
+	// during validation, not by Classify.
+	CatCredential = "credential"
 )
 
 // Engine handles URL classification.
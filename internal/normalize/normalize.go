@@ -2,17 +2,52 @@ package normalize
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"path"
+	"sort"
 	"strings"
 )
 
+// Trailing slash handling.
+const (
+	SlashKeep  = "keep"
+	SlashStrip = "strip"
+)
+
+// DefaultTrackingParams are dropped by StripTracking. A trailing * matches
+// any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "msclkid", "yclid", "igshid", "mc_cid", "mc_eid", "_ga", "_gl",
+}
+
+// Rules are the optional canonicalization steps applied on top of the
+// basic cleanup, so that dedup sees one key per endpoint. Validate only
+// applies them to the dedup key, never to the URL that is probed. The zero value
+// applies none of them; the config enables all but TrailingSlash by default.
+type Rules struct {
+	// SortQuery orders query parameters by name (values of a repeated
+	// name keep their relative order).
+	SortQuery bool
+	// PercentEncoding decodes escaped unreserved characters (%7E -> ~)
+	// and uppercases the hex digits of the remaining escapes.
+	PercentEncoding bool
+	// CleanPath collapses // and resolves . and .. segments.
+	CleanPath bool
+	// StripTracking removes DefaultTrackingParams and TrackingParams.
+	StripTracking  bool
+	TrackingParams []string
+	// TrailingSlash is SlashKeep (default) or SlashStrip.
+	TrailingSlash string
+}
+
 // Normalize cleans up a URL according to standard rules.
 // Rules:
 // - Lowercase scheme and host
 // - Remove fragments (#anchor)
 // - Remove default ports (80/443)
-// - Clean path (resolve ../ ./)
-func Normalize(rawURL string) (string, error) {
+// - Whatever canonicalization the Rules enable
+func Normalize(rawURL string, rules Rules) (string, error) {
 	// 0. Ensure Scheme is present BEFORE parsing
 	// url.Parse("example.com") puts "example.com" in Path, not Host.
 	if !strings.Contains(rawURL, "://") {
@@ -31,27 +66,182 @@ func Normalize(rawURL string) (string, error) {
 
 	// 3. Remove Fragments
 	u.Fragment = ""
+	u.RawFragment = ""
 
 	// 4. Remove Default Ports
 	u.Host = removeDefaultPort(u.Scheme, u.Host)
 
-	// 5. Clean Path
-	// Go's url.Parse cleans path, but doesn't remove trailing slashes always desired?
-	// The prompt didn't specify trailing slash removal, so we stick to robust path.
-	// We do nothing extra here to avoid over-engineering.
+	// 5. Path
+	if err := canonicalPath(u, rules); err != nil {
+		return "", err
+	}
+
+	// 6. Query
+	u.RawQuery = canonicalQuery(u.RawQuery, rules)
+	if u.RawQuery == "" {
+		u.ForceQuery = false
+	}
 
 	return u.String(), nil
 }
 
+// canonicalPath applies the path rules, working on the escaped form so
+// that encoded slashes and the like survive.
+func canonicalPath(u *url.URL, rules Rules) error {
+	p := u.EscapedPath()
+	original := p
+
+	if rules.PercentEncoding {
+		p = normalizePercent(p)
+	}
+
+	if rules.CleanPath && p != "" {
+		cleaned := path.Clean(p)
+		if strings.HasSuffix(p, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+		p = cleaned
+	}
+
+	if rules.TrailingSlash == SlashStrip {
+		if p == "" {
+			p = "/"
+		} else if len(p) > 1 {
+			p = strings.TrimRight(p, "/")
+			if p == "" {
+				p = "/"
+			}
+		}
+	}
+
+	if p == original {
+		return nil
+	}
+
+	unescaped, err := url.PathUnescape(p)
+	if err != nil {
+		return err
+	}
+	u.Path = unescaped
+	u.RawPath = p
+	return nil
+}
+
+// canonicalQuery applies the query rules to a raw query string. Values are
+// left encoded as the source reported them.
+func canonicalQuery(raw string, rules Rules) string {
+	if raw == "" || !(rules.StripTracking || rules.PercentEncoding || rules.SortQuery) {
+		return raw
+	}
+
+	var tracking []string
+	if rules.StripTracking {
+		tracking = append(append(tracking, DefaultTrackingParams...), rules.TrackingParams...)
+	}
+
+	var pairs []string
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		if rules.PercentEncoding {
+			pair = normalizePercent(pair)
+		}
+		if rules.StripTracking && isTracking(paramName(pair), tracking) {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+
+	if rules.SortQuery {
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return paramName(pairs[i]) < paramName(pairs[j])
+		})
+	}
+	return strings.Join(pairs, "&")
+}
+
+// paramName returns the decoded name of a "name=value" pair.
+func paramName(pair string) string {
+	name, _, _ := strings.Cut(pair, "=")
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
+
+func isTracking(name string, tracking []string) bool {
+	name = strings.ToLower(name)
+	for _, t := range tracking {
+		t = strings.ToLower(t)
+		if prefix, ok := strings.CutSuffix(t, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == t {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePercent decodes escapes of unreserved characters and uppercases
+// the rest (RFC 3986 section 6.2.2).
+func normalizePercent(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
 func removeDefaultPort(scheme, host string) string {
-	parts := strings.Split(host, ":")
-	if len(parts) != 2 {
-		return host
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return host // no port
 	}
 
-	domain, port := parts[0], parts[1]
 	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
-		return domain
+		if strings.Contains(hostname, ":") {
+			return "[" + hostname + "]" // IPv6 literal
+		}
+		return hostname
 	}
 
 	return host
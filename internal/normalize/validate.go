@@ -0,0 +1,200 @@
+package normalize
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Reasons a record is rejected by Validate.
+const (
+	RejectEmpty      = "empty"
+	RejectUnparsable = "unparsable"
+	RejectScheme     = "unsupported scheme"
+	RejectNoHost     = "missing host"
+	RejectHost       = "invalid host"
+	RejectPort       = "invalid port"
+)
+
+// RejectError explains why a URL could not be salvaged.
+type RejectError struct {
+	Reason string
+	URL    string
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("%s: %q", e.Reason, e.URL)
+}
+
+// Result is a validated, normalized URL.
+type Result struct {
+	// URL has only the basic cleanup applied; it is what gets probed and
+	// written, so paths like /static/../admin reach the target as reported.
+	URL string
+	// Key is URL with the Rules applied, for dedup and the history.
+	Key string
+	// Credentials is set when the source URL carried user:pass@ (or a bare
+	// token) in its userinfo. It is stripped from URL but worth reporting.
+	Credentials bool
+	// Leaked is the repaired source URL, userinfo included, when
+	// Credentials is set.
+	Leaked string
+}
+
+var (
+	// http://http://example.com, https://http//example.com and similar;
+	// the inner scheme is kept, its separator rebuilt
+	doubledScheme = regexp.MustCompile(`(?i)^https?:?/+(https?):?/+`)
+	// DNS labels, plus the underscores real-world hosts use anyway
+	hostLabel = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]{0,61}[a-z0-9_])?$`)
+)
+
+// Validate repairs what it can in a raw URL from a source, rejects what it
+// cannot, and then applies Normalize: without rules for Result.URL, with
+// them for Result.Key. Repairs cover stray whitespace,
+// doubled schemes, trailing dots in hosts, Unicode (IDN) hosts and
+// userinfo, which is dropped and reported through Result.Credentials.
+func Validate(rawURL string, rules Rules) (Result, error) {
+	raw := repair(rawURL)
+	if raw == "" {
+		return Result{}, &RejectError{Reason: RejectEmpty, URL: rawURL}
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Result{}, &RejectError{Reason: RejectUnparsable, URL: rawURL}
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Result{}, &RejectError{Reason: RejectScheme, URL: rawURL}
+	}
+
+	var res Result
+	if u.User != nil {
+		res.Credentials = u.User.String() != ""
+		if res.Credentials {
+			res.Leaked = raw
+		}
+		u.User = nil
+	}
+
+	host, reason := validHost(u)
+	if reason != "" {
+		return Result{}, &RejectError{Reason: reason, URL: rawURL}
+	}
+	u.Host = host
+
+	res.URL, err = Normalize(u.String(), Rules{})
+	if err != nil {
+		return Result{}, &RejectError{Reason: RejectUnparsable, URL: rawURL}
+	}
+	res.Key, err = Normalize(res.URL, rules)
+	if err != nil {
+		return Result{}, &RejectError{Reason: RejectUnparsable, URL: rawURL}
+	}
+	return res, nil
+}
+
+// repair fixes malformations that are clearly accidental.
+func repair(raw string) string {
+	// Line breaks and tabs inside a URL are copy/paste damage
+	raw = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, raw)
+	raw = strings.TrimSpace(raw)
+
+	for {
+		m := doubledScheme.FindStringSubmatchIndex(raw)
+		if m == nil {
+			break
+		}
+		raw = raw[m[2]:m[3]] + "://" + raw[m[1]:]
+	}
+	return raw
+}
+
+// validHost returns the canonical host[:port] of u, or why it is unusable.
+func validHost(u *url.URL) (string, string) {
+	hostname, port := u.Hostname(), u.Port()
+	if port == "" && strings.HasSuffix(u.Host, ":") {
+		return "", RejectPort
+	}
+
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", RejectPort
+		}
+	}
+
+	hostname = strings.TrimRight(hostname, ".")
+	if hostname == "" {
+		return "", RejectNoHost
+	}
+
+	if strings.Contains(hostname, ":") {
+		// IPv6 literal (Hostname strips the brackets)
+		if net.ParseIP(hostname) == nil {
+			return "", RejectHost
+		}
+		if port == "" {
+			return "[" + hostname + "]", ""
+		}
+		return net.JoinHostPort(hostname, port), ""
+	}
+
+	ascii, ok := asciiHost(hostname)
+	if !ok || len(ascii) > 253 {
+		return "", RejectHost
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if !hostLabel.MatchString(label) {
+			return "", RejectHost
+		}
+	}
+
+	if port == "" {
+		return ascii, ""
+	}
+	return net.JoinHostPort(ascii, port), ""
+}
+
+// asciiHost returns the lowercase ASCII form of a hostname. Unicode hosts,
+// and ASCII ones carrying punycode labels, go through IDNA lookup (NFC and
+// the UTS #46 mapping, then label validation), so every spelling of an IDN
+// host ends up with the same key. Plain ASCII hosts skip it: the STD3 rules
+// it enforces would reject the underscores real-world hosts use anyway.
+func asciiHost(hostname string) (string, bool) {
+	lower := strings.ToLower(hostname)
+	if isASCII(lower) && !strings.HasPrefix(lower, "xn--") && !strings.Contains(lower, ".xn--") {
+		return lower, true
+	}
+
+	ascii, err := idna.Lookup.ToASCII(hostname)
+	if err != nil {
+		return "", false
+	}
+	return ascii, true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
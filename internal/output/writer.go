@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
+	"github.com/bratyabasu07/deflot/internal/dedup"
 	"github.com/bratyabasu07/deflot/internal/filters"
 )
 
@@ -22,6 +25,10 @@ type Writer struct {
 	mainWriter      *bufio.Writer
 	categoryFiles   map[string]*os.File
 	categoryWriters map[string]*bufio.Writer
+
+	// URLs no earlier scan saw, opened on the first one
+	newFile   *os.File
+	newWriter *bufio.Writer
 }
 
 // New creates a new Writer instance.
@@ -83,9 +90,20 @@ func (w *Writer) Write(record appCtx.ScanRecord) error {
 		w.mainWriter.WriteString(line + "\n")
 	}
 
+	if record.New && w.appCtx.OutputDir != "" {
+		if err := w.writeNew(record.URL); err != nil {
+			return err
+		}
+	}
+
 	// Write to category file if applicable
 	if record.Category != "" && record.Category != "none" && w.appCtx.OutputDir != "" {
-		if err := w.writeCategoryFile(record.Category, line); err != nil {
+		// The leak file is only useful with the credentials still in it
+		categoryLine := line
+		if record.LeakedURL != "" && !w.appCtx.JSON {
+			categoryLine = record.LeakedURL
+		}
+		if err := w.writeCategoryFile(record.Category, categoryLine); err != nil {
 			return err
 		}
 	}
@@ -115,11 +133,112 @@ func (w *Writer) writeCategoryFile(category, line string) error {
 	return nil
 }
 
+// writeNew appends a URL to today's new_urls_YYYY-MM-DD.txt. Reruns on the
+// same day append to the same file.
+func (w *Writer) writeNew(u string) error {
+	if w.newWriter == nil {
+		name := fmt.Sprintf("new_urls_%s.txt", time.Now().Format("2006-01-02"))
+		f, err := os.OpenFile(filepath.Join(w.appCtx.OutputDir, name), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", name, err)
+		}
+		w.newFile = f
+		w.newWriter = bufio.NewWriter(f)
+	}
+
+	w.newWriter.WriteString(u + "\n")
+	return nil
+}
+
+// sightingRecord is one line of sightings.json.
+type sightingRecord struct {
+	URL         string    `json:"normalized_url"`
+	Sources     []string  `json:"sources"`
+	SourceCount int       `json:"source_count"`
+	FirstSeen   time.Time `json:"first_seen,omitzero"`
+	LastSeen    time.Time `json:"last_seen,omitzero"`
+}
+
+// WriteSightings writes sightings.json once the scan is over. Records are
+// streamed as soon as a URL is first seen, so sources that report it later
+// only show up here. Lines are ordered by source count, then most recent
+// capture, which is the order we triage in.
+func (w *Writer) WriteSightings(d *dedup.Dedup) error {
+	if !w.appCtx.JSON || w.appCtx.OutputDir == "" {
+		return nil
+	}
+
+	sightings := d.Sightings()
+	if len(sightings) == 0 {
+		return nil // --no-dedup and the Bloom backend keep no sightings
+	}
+
+	records := make([]sightingRecord, 0, len(sightings))
+	for _, seen := range sightings {
+		records = append(records, sightingRecord{
+			URL:         seen.URL,
+			Sources:     seen.Sources,
+			SourceCount: len(seen.Sources),
+			FirstSeen:   seen.FirstSeen,
+			LastSeen:    seen.LastSeen,
+		})
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].SourceCount != records[j].SourceCount {
+			return records[i].SourceCount > records[j].SourceCount
+		}
+		if !records[i].LastSeen.Equal(records[j].LastSeen) {
+			return records[i].LastSeen.After(records[j].LastSeen)
+		}
+		return records[i].URL < records[j].URL
+	})
+
+	f, err := os.Create(filepath.Join(w.appCtx.OutputDir, "sightings.json"))
+	if err != nil {
+		return fmt.Errorf("failed to create sightings file: %w", err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	enc := json.NewEncoder(bw)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteTemplates writes templates.txt, one "count<TAB>template" line per
+// structural template, most common first. It is a no-op without
+// structural dedup.
+func (w *Writer) WriteTemplates(d *dedup.Dedup) error {
+	templates, _ := d.Templates()
+	if len(templates) == 0 || w.appCtx.OutputDir == "" {
+		return nil
+	}
+
+	f, err := os.Create(filepath.Join(w.appCtx.OutputDir, "templates.txt"))
+	if err != nil {
+		return fmt.Errorf("failed to create templates file: %w", err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	for _, t := range templates {
+		fmt.Fprintf(bw, "%d\t%s\n", t.Count, t.Template)
+	}
+	return bw.Flush()
+}
+
 // getCategoryFilename maps category to filename.
 func getCategoryFilename(category string) string {
 	switch category {
 	case filters.CatSecret:
 		return "secret_urls.txt"
+	case filters.CatCredential:
+		return "credential_leak_urls.txt"
 	case filters.CatConfig:
 		return "config_urls.txt"
 	case filters.CatBackup:
@@ -164,6 +283,11 @@ func (w *Writer) Close() error {
 		w.mainFile.Close()
 	}
 
+	if w.newWriter != nil {
+		w.newWriter.Flush()
+		w.newFile.Close()
+	}
+
 	// Flush and close category files
 	for cat, writer := range w.categoryWriters {
 		writer.Flush()
@@ -2,6 +2,8 @@ package pipeline
 
 import (
 	"context"
+	"errors"
+	"github.com/bratyabasu07/deflot/internal/archive"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 	"github.com/bratyabasu07/deflot/internal/dedup"
 	"github.com/bratyabasu07/deflot/internal/filters"
@@ -15,6 +17,10 @@ import (
 	"time"
 )
 
+// archiveQueueSize bounds the dead URLs waiting for an archive download.
+// When it fills up, pipeline workers wait instead of piling up goroutines.
+const archiveQueueSize = 256
+
 // Pipeline orchestrates the flow of data.
 type Pipeline struct {
 	appCtx  *appCtx.AppContext
@@ -25,13 +31,19 @@ type Pipeline struct {
 	stats   *summary.Stats
 
 	jsScanner *jssecrethunter.Scanner
+	archiver  *archive.Fetcher
 	scannerWg sync.WaitGroup
 
+	archiveQueue chan string
+	archiveWg    sync.WaitGroup
+
+	rules normalize.Rules
+
 	notify func(string)
 }
 
 // New creates a new pipeline instance.
-func New(ctx *appCtx.AppContext, d *dedup.Dedup, c *status.Checker, f *filters.Engine, w *output.Writer, s *summary.Stats, notify func(string), js *jssecrethunter.Scanner) *Pipeline {
+func New(ctx *appCtx.AppContext, d *dedup.Dedup, c *status.Checker, f *filters.Engine, w *output.Writer, s *summary.Stats, notify func(string), js *jssecrethunter.Scanner, a *archive.Fetcher, rules normalize.Rules) *Pipeline {
 	return &Pipeline{
 		appCtx:    ctx,
 		dedup:     d,
@@ -41,6 +53,8 @@ func New(ctx *appCtx.AppContext, d *dedup.Dedup, c *status.Checker, f *filters.E
 		stats:     s,
 		notify:    notify,
 		jsScanner: js,
+		archiver:  a,
+		rules:     rules,
 	}
 }
 
@@ -64,6 +78,17 @@ func (p *Pipeline) Start(ctx context.Context, input <-chan appCtx.ScanRecord) <-
 	// We use a buffered channel for intermediate steps if needed, but here we can just
 	// fan out consumers from the single input channel.
 
+	if p.archiver.Enabled() {
+		p.archiveQueue = make(chan string, archiveQueueSize)
+		for i := 0; i < archive.MaxConcurrent; i++ {
+			p.archiveWg.Add(1)
+			go func() {
+				defer p.archiveWg.Done()
+				p.archiveWorker(ctx)
+			}()
+		}
+	}
+
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
 		go func(id int) {
@@ -74,7 +99,11 @@ func (p *Pipeline) Start(ctx context.Context, input <-chan appCtx.ScanRecord) <-
 
 	// Waiter routine
 	go func() {
-		wg.Wait()          // Wait for all workers to finish processing stream
+		wg.Wait() // Wait for all workers to finish processing stream
+		if p.archiveQueue != nil {
+			close(p.archiveQueue)
+			p.archiveWg.Wait() // Drain the archive downloads still queued
+		}
 		p.scannerWg.Wait() // Wait for any async background scanners
 		close(done)
 	}()
@@ -117,29 +146,58 @@ func (p *Pipeline) worker(ctx context.Context, input <-chan appCtx.ScanRecord) {
 func (p *Pipeline) processRecord(record appCtx.ScanRecord) {
 	p.stats.IncTotal()
 
-	// 1. Normalize
-	validatedURL, err := normalize.Normalize(record.URL)
+	// 1. Validate & Normalize
+	validated, err := normalize.Validate(record.URL, p.rules)
 	if err != nil {
+		var rejected *normalize.RejectError
+		if errors.As(err, &rejected) {
+			p.stats.IncRejected(rejected.Reason)
+		}
 		return // Skip invalid
 	}
-	record.URL = validatedURL
+	record.URL = validated.URL
 
 	// 2. Dedup Gate
-	if p.dedup.Check(record.URL) == dedup.Drop {
+	// Dedup runs on the canonical key; the URL itself stays as reported
+	result, seen := p.dedup.Observe(validated.Key, record.Source, record.CapturedAt)
+	if result == dedup.Drop {
 		return
 	}
 	p.stats.IncDedup()
+	record.Sources = seen.Sources
+	record.FirstSeen = seen.FirstSeen
+	record.LastSeen = seen.LastSeen
+	record.New = seen.New
 
 	// 3. Status Gate (if enabled checks)
-	passed, code := p.checker.Check(record.URL)
-	if !passed {
+	verdict, probe := p.checker.Check(record.URL)
+	if verdict != status.Passed {
+		// Live pages dropped by --fc, the filters or calibration are not
+		// worth an archive lookup
+		if verdict.Dead(probe) {
+			p.recoverArchived(record.URL)
+		}
 		return
 	}
-	record.StatusCode = code
+	record.StatusCode = probe.StatusCode
+	record.Title = probe.Title
+	if probe.ContentLength > 0 {
+		record.ContentLength = probe.ContentLength
+	}
+	record.ContentType = probe.ContentType
+	record.Server = probe.Server
+	record.FinalURL = probe.FinalURL
+	record.RedirectChain = probe.RedirectChain
 	p.stats.IncStatus()
 
 	// 4. Filter Classification
-	category := p.filter.Classify(record.URL)
+	// Credentials in the URL outrank anything the path says
+	category := filters.CatCredential
+	if validated.Credentials {
+		record.LeakedURL = validated.Leaked
+	} else {
+		category = p.filter.Classify(record.URL)
+	}
 	if category != "none" {
 		p.stats.IncCategory(category)
 		if p.notify != nil {
@@ -162,5 +220,33 @@ func (p *Pipeline) processRecord(record appCtx.ScanRecord) {
 	// If category is none, but we passed all gates, we output to default list logic inside writer
 	if err := p.writer.Write(record); err != nil {
 		// Log error?
+		return
+	}
+	p.dedup.Remember(validated.Key)
+}
+
+// recoverArchived queues a dead URL for the archive workers when it looks
+// sensitive.
+func (p *Pipeline) recoverArchived(url string) {
+	if p.archiveQueue == nil {
+		return
+	}
+	if !archive.Wants(p.filter.Classify(url)) {
+		return
+	}
+	p.archiveQueue <- url
+}
+
+// archiveWorker fetches the last archived copy of queued URLs until the
+// queue is closed.
+func (p *Pipeline) archiveWorker(ctx context.Context) {
+	for url := range p.archiveQueue {
+		saved, err := p.archiver.Fetch(ctx, url)
+		if err != nil {
+			continue // Not archived or archive unreachable; the URL is just dead
+		}
+		if saved {
+			p.stats.IncArchived()
+		}
 	}
 }
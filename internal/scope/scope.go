@@ -0,0 +1,237 @@
+package scope
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Scope is a bug bounty program's scope: include and exclude rules read
+// from a file, one per line. Supported entries:
+//
+//	example.com            exact host
+//	*.example.com          wildcard (subdomains only)
+//	10.0.0.0/24            CIDR range (IP-literal hosts only)
+//	/static/               path prefix on any host
+//	example.com/admin      path prefix on one host
+//	re:^https://api\d+\.   regular expression on the full URL
+//
+// A leading "!" turns a rule into an exclude; "#" starts a comment. Path
+// prefixes end on a segment boundary, so /admin does not cover
+// /administrator. CIDR rules are checked against the URL's host as written
+// and never resolve names, so they only cover URLs that use an IP address.
+type Scope struct {
+	includes []*rule
+	excludes []*rule
+}
+
+type rule struct {
+	raw  string
+	host string         // exact host, empty if any
+	glob *regexp.Regexp // wildcard host
+	cidr *net.IPNet
+	path string // path prefix, empty if any
+	re   *regexp.Regexp
+}
+
+// Load parses a scope file.
+func Load(path string) (*Scope, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scope file: %w", err)
+	}
+	defer f.Close()
+
+	s := &Scope{}
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		exclude := strings.HasPrefix(line, "!")
+		r, err := parseRule(strings.TrimSpace(strings.TrimPrefix(line, "!")))
+		if err != nil {
+			return nil, fmt.Errorf("scope file line %d: %w", lineNum, err)
+		}
+		r.raw = line
+
+		if exclude {
+			s.excludes = append(s.excludes, r)
+		} else {
+			s.includes = append(s.includes, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading scope file: %w", err)
+	}
+
+	if len(s.includes) == 0 && len(s.excludes) == 0 {
+		return nil, fmt.Errorf("scope file contains no rules")
+	}
+	return s, nil
+}
+
+func parseRule(entry string) (*rule, error) {
+	if entry == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+
+	if expr, ok := strings.CutPrefix(entry, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", expr, err)
+		}
+		return &rule{re: re}, nil
+	}
+
+	if _, cidr, err := net.ParseCIDR(entry); err == nil {
+		return &rule{cidr: cidr}, nil
+	}
+
+	// Programs often list scope as URLs; the scheme doesn't matter here
+	if i := strings.Index(entry, "://"); i >= 0 {
+		entry = entry[i+3:]
+	}
+
+	host, path := entry, ""
+	if i := strings.Index(entry, "/"); i >= 0 {
+		host, path = entry[:i], entry[i:]
+	}
+	host = strings.ToLower(host)
+
+	r := &rule{path: path}
+	switch {
+	case host == "":
+		// Path-only rule
+	case strings.Contains(host, "*"):
+		pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(host), `\*`, `.*`) + "$"
+		r.glob = regexp.MustCompile(pattern)
+	default:
+		r.host = host
+	}
+	return r, nil
+}
+
+// matches reports whether the rule covers u. host is u's lowercased hostname.
+func (r *rule) matches(u *url.URL, host string) bool {
+	if r.re != nil {
+		return r.re.MatchString(u.String())
+	}
+
+	if r.cidr != nil {
+		// No DNS lookup: a name pointing into the range does not match
+		ip := net.ParseIP(host)
+		return ip != nil && r.cidr.Contains(ip)
+	}
+
+	if r.host != "" && host != r.host {
+		return false
+	}
+	if r.glob != nil && !r.glob.MatchString(host) {
+		return false
+	}
+
+	if r.path != "" {
+		path := u.Path
+		if path == "" {
+			path = "/"
+		}
+		prefix := strings.TrimSuffix(r.path, "/")
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return true
+}
+
+// HasIncludes reports whether the file lists what is in scope, as opposed
+// to only carving exclusions out of the target.
+func (s *Scope) HasIncludes() bool {
+	return len(s.includes) > 0
+}
+
+// Excluded returns the exclude rule covering u, if any.
+func (s *Scope) Excluded(u *url.URL) (string, bool) {
+	host := strings.ToLower(u.Hostname())
+	for _, r := range s.excludes {
+		if r.matches(u, host) {
+			return r.raw, true
+		}
+	}
+	return "", false
+}
+
+// Included reports whether any include rule covers u.
+func (s *Scope) Included(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	for _, r := range s.includes {
+		if r.matches(u, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log records each out-of-scope URL once, with the rule that excluded it,
+// as proof that those assets were never probed.
+type Log struct {
+	mu     sync.Mutex
+	seen   map[string]struct{}
+	file   *os.File
+	writer *bufio.Writer
+}
+
+// OpenLog creates (or truncates) the log at path.
+func OpenLog(path string) (*Log, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create out-of-scope log: %w", err)
+	}
+	return &Log{
+		seen:   make(map[string]struct{}),
+		file:   f,
+		writer: bufio.NewWriter(f),
+	}, nil
+}
+
+// Record writes one "url<TAB>rule" line. Nil logs ignore it.
+func (l *Log) Record(rawURL, rule string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[rawURL]; ok {
+		return
+	}
+	l.seen[rawURL] = struct{}{}
+	l.writer.WriteString(rawURL + "\t" + rule + "\n")
+}
+
+// Count returns how many distinct URLs were logged.
+func (l *Log) Count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.seen)
+}
+
+// Close flushes the log to disk.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.writer.Flush(); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
@@ -0,0 +1,76 @@
+package scope
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadScope(t *testing.T, content string) *Scope {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scope.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return s
+}
+
+func TestScopeRules(t *testing.T) {
+	s := loadScope(t, `
+# Program scope
+example.com
+*.example.com
+https://partner.example.net/api
+10.0.0.0/24
+re:^https://cdn\d+\.example\.org/
+
+!blog.example.com
+!/static/
+`)
+
+	tests := []struct {
+		url      string
+		in       bool
+		excluded string
+	}{
+		{"https://example.com/login", true, ""},
+		{"https://api.example.com/v1", true, ""},
+		{"https://partner.example.net/api/users", true, ""},
+		{"https://partner.example.net/home", false, ""},
+		{"https://partner.example.net/api", true, ""},
+		{"https://partner.example.net/apiary", false, ""},
+		{"http://10.0.0.7:8080/", true, ""},
+		{"http://10.0.1.7/", false, ""},
+		{"https://cdn2.example.org/app.js", true, ""},
+		{"https://other.com/", false, ""},
+		{"https://blog.example.com/post", false, "!blog.example.com"},
+		{"https://example.com/static/app.js", false, "!/static/"},
+		{"https://example.com/static-old/app.js", true, ""},
+	}
+
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		rule, excluded := s.Excluded(u)
+		if rule != tt.excluded {
+			t.Errorf("%s: expected exclude rule %q, got %q", tt.url, tt.excluded, rule)
+		}
+		if in := !excluded && s.Included(u); in != tt.in {
+			t.Errorf("%s: expected in scope = %v", tt.url, tt.in)
+		}
+	}
+}
+
+func TestLoadRejectsBadRegex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scope.txt")
+	os.WriteFile(path, []byte("example.com\nre:([\n"), 0644)
+
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected a line 2 error, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+const (
+	defaultCommonCrawlURL         = "https://index.commoncrawl.org"
+	defaultCommonCrawlCollections = 3
+)
+
+func init() {
+	Register(Info{
+		Name:           "commoncrawl",
+		Description:    "Common Crawl CDX index (recent crawls)",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewCommonCrawl(domain, cfg)
+	})
+}
+
+type CommonCrawl struct {
+	metered
+	domain      string
+	baseURL     string
+	collections int
+}
+
+func NewCommonCrawl(domain string, cfg config.Config) *CommonCrawl {
+	baseURL := strings.TrimSuffix(cfg.Sources.CommonCrawl.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultCommonCrawlURL
+	}
+
+	collections := cfg.Sources.CommonCrawl.Collections
+	if collections < 1 {
+		collections = defaultCommonCrawlCollections
+	}
+
+	return &CommonCrawl{
+		metered:     newMetered("commoncrawl", cfg),
+		domain:      domain,
+		baseURL:     baseURL,
+		collections: collections,
+	}
+}
+
+func (s *CommonCrawl) Name() string {
+	return "commoncrawl"
+}
+
+func (s *CommonCrawl) NeedsKey() bool {
+	return false
+}
+
+// Test checks that the endpoint answers.
+func (s *CommonCrawl) Test(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/collinfo.json", nil)
+	if err != nil {
+		return err
+	}
+	return probe(req)
+}
+
+type ccCollection struct {
+	ID string `json:"id"`
+}
+
+type ccNumPages struct {
+	Pages int `json:"pages"`
+}
+
+type ccRecord struct {
+	URL       string `json:"url"`
+	Timestamp string `json:"timestamp"`
+}
+
+func (s *CommonCrawl) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
+	client := &http.Client{
+		Timeout: 45 * time.Second, // Index servers are slow on large domains
+	}
+
+	collections, err := s.fetchCollections(ctx, client)
+	if errors.Is(err, ErrQuotaExhausted) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] CommonCrawl collinfo failed: %v\n", err)
+		return
+	}
+
+	// collinfo.json is ordered newest first
+	if len(collections) > s.collections {
+		collections = collections[:s.collections]
+	}
+
+	for _, coll := range collections {
+		if ctx.Err() != nil {
+			return
+		}
+
+		indexURL := fmt.Sprintf("%s/%s-index", s.baseURL, coll.ID)
+
+		pages, err := s.numPages(ctx, client, indexURL)
+		if errors.Is(err, ErrQuotaExhausted) {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[!] CommonCrawl %s: %v\n", coll.ID, err)
+			continue
+		}
+
+		for page := 0; page < pages; page++ {
+			if ctx.Err() != nil {
+				return
+			}
+			err := s.streamPage(ctx, client, indexURL, page, results)
+			if errors.Is(err, ErrQuotaExhausted) {
+				return
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "[!] CommonCrawl %s page %d failed: %v\n", coll.ID, page, err)
+			}
+		}
+	}
+}
+
+// fetchCollections lists the available crawls from collinfo.json.
+func (s *CommonCrawl) fetchCollections(ctx context.Context, client *http.Client) ([]ccCollection, error) {
+	var collections []ccCollection
+
+	if !s.acquire(ctx, "CommonCrawl") {
+		return nil, ErrQuotaExhausted
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/collinfo.json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&collections)
+	return collections, err
+}
+
+// query builds the CDX query string shared by the page count and page requests.
+func (s *CommonCrawl) query() url.Values {
+	q := url.Values{}
+	q.Set("url", s.domain)
+	q.Set("matchType", "domain")
+	q.Set("output", "json")
+	q.Set("fl", "url,timestamp")
+	return q
+}
+
+// numPages asks the index how many result pages exist for the domain.
+func (s *CommonCrawl) numPages(ctx context.Context, client *http.Client, indexURL string) (int, error) {
+	q := s.query()
+	q.Set("showNumPages", "true")
+
+	var data ccNumPages
+	if !s.acquire(ctx, "CommonCrawl") {
+		return 0, ErrQuotaExhausted
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", indexURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := s.do(ctx, client, req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// The index answers 404 when the domain has no captures in this crawl
+	if resp.StatusCode == 404 {
+		return 0, nil
+	}
+	if resp.StatusCode != 200 {
+		return 0, fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&data)
+	return data.Pages, err
+}
+
+// streamPage pushes every URL on a single index page to the results channel.
+func (s *CommonCrawl) streamPage(ctx context.Context, client *http.Client, indexURL string, page int, results chan<- appCtx.ScanRecord) error {
+	q := s.query()
+	q.Set("page", fmt.Sprintf("%d", page))
+	apiURL := indexURL + "?" + q.Encode()
+
+	if !s.acquire(ctx, "CommonCrawl") {
+		return ErrQuotaExhausted
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil
+	}
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	for scanner.Scan() {
+		var rec ccRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.URL == "" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case results <- appCtx.ScanRecord{
+			URL:        rec.URL,
+			Source:     "commoncrawl",
+			Category:   "none",
+			CapturedAt: parseSourceTime(rec.Timestamp),
+		}:
+		}
+	}
+
+	return scanner.Err()
+}
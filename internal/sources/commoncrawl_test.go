@@ -0,0 +1,76 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestCommonCrawlRun(t *testing.T) {
+	var mu sync.Mutex
+	var queried []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path == "/collinfo.json" {
+			fmt.Fprint(w, `[{"id": "CC-MAIN-2024-10"}, {"id": "CC-MAIN-2024-05"}, {"id": "CC-MAIN-2023-50"}]`)
+			return
+		}
+		if q.Get("url") != "example.com" || q.Get("matchType") != "domain" || q.Get("fl") != "url,timestamp" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+
+		mu.Lock()
+		queried = append(queried, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/CC-MAIN-2024-10-index":
+			if q.Get("showNumPages") == "true" {
+				fmt.Fprint(w, `{"pages": 2}`)
+				return
+			}
+			fmt.Fprintf(w, "{\"url\": \"https://example.com/p%s\", \"timestamp\": \"20240301120000\"}\nnot json\n", q.Get("page"))
+		case "/CC-MAIN-2024-05-index":
+			http.NotFound(w, r) // no captures in this crawl
+		default:
+			t.Errorf("collection beyond the configured count queried: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{RateLimits: map[string]config.RateLimit{"commoncrawl": {}}}
+	cfg.Sources.CommonCrawl.BaseURL = srv.URL + "/"
+	cfg.Sources.CommonCrawl.Collections = 2
+	s := NewCommonCrawl("example.com", cfg)
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	for r := range results {
+		if want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC); !r.CapturedAt.Equal(want) {
+			t.Errorf("%s: expected capture date %v, got %v", r.URL, want, r.CapturedAt)
+		}
+		urls = append(urls, r.URL)
+	}
+	sort.Strings(urls)
+	if want := []string{"https://example.com/p0", "https://example.com/p1"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+
+	// Page count plus two pages from the newest crawl, one 404 from the next
+	if len(queried) != 4 {
+		t.Errorf("Expected 4 index requests, got %v", queried)
+	}
+}
@@ -0,0 +1,168 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+const defaultCTEndpoint = "https://crt.sh/"
+
+func init() {
+	Register(Info{
+		Name:           "ct",
+		Description:    "Certificate-transparency log search (crt.sh)",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewCertTransparency(domain, cfg)
+	})
+}
+
+// CertTransparency discovers hostnames from certificate-transparency log search.
+type CertTransparency struct {
+	metered
+	domain   string
+	endpoint string
+}
+
+func NewCertTransparency(domain string, cfg config.Config) *CertTransparency {
+	endpoint := cfg.Sources.CT.Endpoint
+	if endpoint == "" {
+		endpoint = defaultCTEndpoint
+	}
+
+	return &CertTransparency{
+		metered:  newMetered("ct", cfg),
+		domain:   domain,
+		endpoint: endpoint,
+	}
+}
+
+func (s *CertTransparency) Name() string {
+	return "ct"
+}
+
+func (s *CertTransparency) NeedsKey() bool {
+	return false
+}
+
+// Test checks that the endpoint answers.
+func (s *CertTransparency) Test(ctx context.Context) error {
+	q := url.Values{}
+	q.Set("q", s.domain)
+	q.Set("output", "json")
+
+	sep := "?"
+	if strings.Contains(s.endpoint, "?") {
+		sep = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.endpoint+sep+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return probe(req)
+}
+
+type ctEntry struct {
+	NameValue  string `json:"name_value"`
+	CommonName string `json:"common_name"`
+}
+
+func (s *CertTransparency) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
+	q := url.Values{}
+	q.Set("q", "%."+s.domain)
+	q.Set("output", "json")
+
+	apiURL := s.endpoint
+	if strings.Contains(apiURL, "?") {
+		apiURL += "&" + q.Encode()
+	} else {
+		apiURL += "?" + q.Encode()
+	}
+
+	// crt.sh is notoriously slow for popular roots
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	// Certificates repeat the same names across renewals, so collapse them
+	// here instead of flooding the pipeline with identical hosts.
+	seen := make(map[string]bool)
+
+	if !s.acquire(ctx, "CT") {
+		return
+	}
+
+	if err := s.stream(ctx, client, apiURL, seen, results); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "[!] CT Failed: %v\n", err)
+	}
+}
+
+// stream decodes the crt.sh JSON array and emits each new hostname.
+func (s *CertTransparency) stream(ctx context.Context, client *http.Client, apiURL string, seen map[string]bool, results chan<- appCtx.ScanRecord) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	// Decode the array element by element to avoid holding it all in memory
+	dec := json.NewDecoder(resp.Body)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	for dec.More() {
+		var entry ctEntry
+		if err := dec.Decode(&entry); err != nil {
+			return err
+		}
+
+		for _, host := range splitCTNames(entry.NameValue, entry.CommonName) {
+			if seen[host] {
+				continue
+			}
+			seen[host] = true
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case results <- appCtx.ScanRecord{URL: host, Source: "ct", Category: "none"}:
+			}
+		}
+	}
+
+	return nil
+}
+
+// splitCTNames turns the newline-separated SAN list of a certificate into
+// clean hostnames, stripping wildcard labels and anything that isn't a host.
+func splitCTNames(values ...string) []string {
+	var hosts []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, "\n") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			name = strings.TrimPrefix(name, "*.")
+			if name == "" || strings.ContainsAny(name, " @*/") {
+				continue
+			}
+			hosts = append(hosts, name)
+		}
+	}
+	return hosts
+}
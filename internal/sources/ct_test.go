@@ -0,0 +1,54 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestSplitCTNames(t *testing.T) {
+	got := splitCTNames("*.Example.com\nwww.example.com\n\n  api.example.com \nadmin@example.com\n*.*.example.com", "example.com")
+	want := []string{"example.com", "www.example.com", "api.example.com", "example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestCertTransparencyRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query(); q.Get("q") != "%.example.com" || q.Get("output") != "json" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `[
+			{"name_value": "*.example.com\nexample.com", "common_name": "*.example.com"},
+			{"name_value": "api.example.com", "common_name": "api.example.com"}
+		]`)
+	}))
+	defer srv.Close()
+
+	var cfg config.Config
+	cfg.Sources.CT.Endpoint = srv.URL + "/"
+	s := NewCertTransparency("example.com", cfg)
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var hosts []string
+	for r := range results {
+		if r.Source != "ct" {
+			t.Errorf("Expected source ct, got %q", r.Source)
+		}
+		hosts = append(hosts, r.URL)
+	}
+	// Renewals and wildcards repeat names; each host is emitted once
+	if want := []string{"example.com", "api.example.com"}; !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Expected %v, got %v", want, hosts)
+	}
+}
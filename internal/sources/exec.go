@@ -0,0 +1,170 @@
+package sources
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+// ExecSource runs an external enumeration tool and streams its stdout lines.
+type ExecSource struct {
+	domain  string
+	name    string
+	command string
+	timeout time.Duration
+}
+
+func NewExecSource(domain string, settings config.ExecSettings) *ExecSource {
+	return &ExecSource{
+		domain:  domain,
+		name:    settings.Name,
+		command: settings.Command,
+		timeout: time.Duration(settings.Timeout) * time.Second,
+	}
+}
+
+func (s *ExecSource) Name() string {
+	return s.name
+}
+
+func (s *ExecSource) NeedsKey() bool {
+	return false
+}
+
+// Test checks that the command's binary can be found.
+func (s *ExecSource) Test(ctx context.Context) error {
+	args := splitCommand(s.command)
+	if len(args) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	return nil
+}
+
+func (s *ExecSource) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
+	args := splitCommand(s.command)
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "[!] %s: empty command\n", s.name)
+		return
+	}
+
+	// Substitute per argument (no shell) so the domain can't inject commands
+	for i := range args {
+		args[i] = strings.ReplaceAll(args[i], "{domain}", s.domain)
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Tools that fork helpers can keep stdout open after being killed;
+	// WaitDelay makes Wait close the pipe so the reader below unblocks.
+	cmd.WaitDelay = 2 * time.Second
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[!] %s: %v\n", s.name, err)
+		return
+	}
+
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "[!] %s: %v\n", s.name, err)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.stream(ctx, stdout, results)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	err = cmd.Wait()
+	<-done // Never return while the reader may still send
+
+	if ctx.Err() == context.DeadlineExceeded && s.timeout > 0 {
+		fmt.Fprintf(os.Stderr, "[!] %s timed out after %v\n", s.name, s.timeout)
+	} else if err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "[!] %s exited: %v\n", s.name, err)
+	}
+}
+
+// stream forwards each non-empty stdout line as a ScanRecord.
+func (s *ExecSource) stream(ctx context.Context, stdout io.Reader, results chan<- appCtx.ScanRecord) {
+	scanner := bufio.NewScanner(stdout)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case results <- appCtx.ScanRecord{URL: line, Source: s.name, Category: "none"}:
+		}
+	}
+
+	// Drain whatever is left (e.g. after an over-long line) so the
+	// child never blocks on a full pipe.
+	_, _ = io.Copy(io.Discard, stdout)
+}
+
+// splitCommand splits a command line into arguments, honouring single and
+// double quotes so templates like `tool -q "a b"` work without a shell.
+func splitCommand(command string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args
+}
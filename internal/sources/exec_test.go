@@ -0,0 +1,94 @@
+package sources
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"subfinder -d {domain} -silent", []string{"subfinder", "-d", "{domain}", "-silent"}},
+		{"tool  -q \"a b\"\t-x", []string{"tool", "-q", "a b", "-x"}},
+		{`tool -q 'say "hi"' ''`, []string{"tool", "-q", `say "hi"`, ""}},
+		{"   ", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitCommand(tt.command); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func execTestSource(t *testing.T, command string, timeout int) *ExecSource {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	return NewExecSource("example.com", config.ExecSettings{Name: "tool", Command: command, Timeout: timeout})
+}
+
+func TestExecSourceRun(t *testing.T) {
+	s := execTestSource(t, `sh -c "echo https://{domain}/a; echo; echo '  https://{domain}/b  '"`, 0)
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	for r := range results {
+		if r.Source != "tool" {
+			t.Errorf("Expected source tool, got %q", r.Source)
+		}
+		urls = append(urls, r.URL)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
+
+func TestExecSourceTimeout(t *testing.T) {
+	s := execTestSource(t, `sh -c "echo https://{domain}/early; exec sleep 30"`, 1)
+
+	results := make(chan appCtx.ScanRecord, 10)
+	start := time.Now()
+	s.Run(context.Background(), results)
+	close(results)
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the timeout to stop the command, took %v", elapsed)
+	}
+	if r, ok := <-results; !ok || r.URL != "https://example.com/early" {
+		t.Errorf("Expected the line printed before the timeout, got %+v", r)
+	}
+}
+
+func TestExecSourceCancel(t *testing.T) {
+	s := execTestSource(t, `sh -c "echo https://{domain}/first; exec sleep 30"`, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan appCtx.ScanRecord)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, results)
+	}()
+
+	<-results
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Run to return once the context is cancelled")
+	}
+}
@@ -3,6 +3,7 @@ package sources
 import (
 	"bufio"
 	"context"
+	"io"
 	"os"
 
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
@@ -35,18 +36,53 @@ func (s *FileSource) Run(ctx context.Context, results chan<- appCtx.ScanRecord)
 	}
 	defer f.Close()
 
-	scanner := bufio.NewScanner(f)
+	streamLines(ctx, f, "file", results)
+}
+
+// StdinSource reads URLs piped into the process (-i - or implicit when stdin is a pipe).
+type StdinSource struct {
+	reader io.Reader
+}
+
+func NewStdinSource() *StdinSource {
+	return &StdinSource{reader: os.Stdin}
+}
+
+func (s *StdinSource) Name() string {
+	return "stdin"
+}
+
+func (s *StdinSource) NeedsKey() bool {
+	return false
+}
+
+func (s *StdinSource) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
+	streamLines(ctx, s.reader, "stdin", results)
+}
+
+// streamLines pushes each line of r into results as it is read, so large
+// inputs are never buffered whole. It returns as soon as ctx is cancelled,
+// including while waiting for the pipeline to accept a record.
+func streamLines(ctx context.Context, r io.Reader, source string, results chan<- appCtx.ScanRecord) {
+	scanner := bufio.NewScanner(r)
+	// Increase buffer size for long URLs
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
 	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
 		select {
 		case <-ctx.Done():
 			return
-		default:
-			line := scanner.Text()
-			results <- appCtx.ScanRecord{
-				URL:      line,
-				Source:   "file",
-				Category: "none",
-			}
+		case results <- appCtx.ScanRecord{
+			URL:      line,
+			Source:   source,
+			Category: "none",
+		}:
 		}
 	}
 }
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestStdinSourceStreams(t *testing.T) {
+	pr, pw := io.Pipe()
+	s := &StdinSource{reader: pr}
+
+	results := make(chan appCtx.ScanRecord)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(context.Background(), results)
+	}()
+
+	// Each line must come through while the writer is still open
+	for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+		go pw.Write([]byte(u + "\n\n"))
+		select {
+		case r := <-results:
+			if r.URL != u || r.Source != "stdin" {
+				t.Errorf("Expected %s from stdin, got %+v", u, r)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected %s before stdin was closed", u)
+		}
+	}
+
+	pw.Close()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Run to return at end of input")
+	}
+}
+
+func TestStdinSourceCancel(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("https://example.com/a\n"))
+
+	s := &StdinSource{reader: pr}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, make(chan appCtx.ScanRecord)) // nobody reads
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected Run to return while blocked on the pipeline")
+	}
+}
+
+func TestFileSourceRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.txt")
+	os.WriteFile(path, []byte("https://example.com/a\n\nhttps://example.com/b\n"), 0644)
+
+	results := make(chan appCtx.ScanRecord, 10)
+	NewFileSource(path).Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	for r := range results {
+		urls = append(urls, r.URL)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
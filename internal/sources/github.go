@@ -4,23 +4,53 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+const (
+	githubAPIURL = "https://api.github.com"
+	// Leaked endpoints sit in config and source files; skip anything huge.
+	githubMaxFileSize = 2 * 1024 * 1024
+)
+
+func init() {
+	Register(Info{
+		Name:           "github",
+		Description:    "GitHub code search (leaked endpoints)",
+		KeyName:        "github",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewGitHub(domain, cfg)
+	})
+}
+
 type GitHub struct {
-	domain string
-	apiKey string
+	metered
+	content metered // file downloads, paced apart from code search
+	domain  string
+	keys    *KeyPool
+	apiURL  string
+	matcher *regexp.Regexp
 }
 
 func NewGitHub(domain string, cfg config.Config) *GitHub {
+	search := newMetered("github", cfg)
 	return &GitHub{
-		domain: domain,
-		apiKey: cfg.ApiKeys.GitHub,
+		metered: search,
+		content: metered{limiter: limiterFor("github_content", cfg), retries: search.retries},
+		domain:  strings.ToLower(domain),
+		keys:    NewKeyPool("GitHub", cfg.ApiKeys.GitHub),
+		apiURL:  githubAPIURL,
+		matcher: targetURLPattern(domain),
 	}
 }
 
@@ -32,15 +62,40 @@ func (s *GitHub) NeedsKey() bool {
 	return true
 }
 
+// Usage counts code searches and file downloads together.
+func (s *GitHub) Usage() Usage {
+	u := s.metered.Usage()
+	u.Calls += s.content.limiter.Usage().Calls
+	return u
+}
+
+// Test checks that the endpoint answers and accepts the key.
+func (s *GitHub) Test(ctx context.Context) error {
+	// /rate_limit does not count against the quota
+	return s.keys.Test(func(key string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", s.apiURL+"/rate_limit", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "token "+key)
+		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		return req, nil
+	})
+}
+
 type githubResponse struct {
 	Items []struct {
-		HTMLURL string `json:"html_url"`
+		URL         string `json:"url"` // Contents API URL for the blob
+		HTMLURL     string `json:"html_url"`
+		TextMatches []struct {
+			Fragment string `json:"fragment"`
+		} `json:"text_matches"`
 	} `json:"items"`
 	IncompleteResults bool `json:"incomplete_results"`
 }
 
 func (s *GitHub) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
-	if s.apiKey == "" {
+	if s.keys.Len() == 0 {
 		return
 	}
 
@@ -50,38 +105,43 @@ func (s *GitHub) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
 	page := 1
 	client := &http.Client{Timeout: 30 * time.Second}
 
+	// The same endpoint tends to be committed in many repos and forks
+	seen := make(map[string]bool)
+
 	for {
-		// Rate limit safe? GitHub search is 30 req/min (~2s delay)
-		// We should respect that or we get 403.
-		// "Respect rate limits... Handle retries internally"
+		key, ok := s.keys.Current()
+		if !ok {
+			return
+		}
+
+		// GitHub search is 30 req/min; the limiter keeps us under that
+		if !s.acquire(ctx, "GitHub") {
+			return
+		}
 
-		apiURL := fmt.Sprintf("https://api.github.com/search/code?q=%s&per_page=100&page=%d", encodedQuery, page)
+		apiURL := fmt.Sprintf("%s/search/code?q=%s&per_page=100&page=%d", s.apiURL, encodedQuery, page)
 		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
 			return
 		}
-		req.Header.Set("Authorization", "token "+s.apiKey)
-		req.Header.Set("Accept", "application/vnd.github.v3+json")
+		req.Header.Set("Authorization", "token "+key)
+		// text-match returns the matching snippets alongside each item
+		req.Header.Set("Accept", "application/vnd.github.v3.text-match+json")
 
-		var resp *http.Response
-		err = WithRetry(func() error {
-			var e error
-			resp, e = client.Do(req)
-			return e
-		}, 3)
+		resp, err := s.doRotating(ctx, client, req, s.keys)
 
 		if err != nil {
-			fmt.Printf("[!] GitHub Request Failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "[!] GitHub Request Failed: %v\n", err)
 			return
 		}
 
-		if resp.StatusCode == 403 || resp.StatusCode == 429 {
-			// Rate limit
+		if resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 429 {
+			// Bad key or rate limit: carry on with the next key if there is one.
+			// Blocking until the reset would stall the stream, so otherwise stop.
 			resp.Body.Close()
-			time.Sleep(2 * time.Second) // Basic
-			// For robust impl, read X-RateLimit-Reset. But simplest is sleep and retry or stop.
-			// Given "streaming", blocking 60s is bad.
-			// We'll stop on heavy rate limit.
+			if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+				continue
+			}
 			break
 		}
 
@@ -98,14 +158,31 @@ func (s *GitHub) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
 		resp.Body.Close()
 
 		for _, item := range data.Items {
-			select {
-			case <-ctx.Done():
-				return
-			case results <- appCtx.ScanRecord{
-				URL:      item.HTMLURL,
-				Source:   "github",
-				Category: "none",
-			}:
+			// Prefer the whole file; fall back to the snippets if it can't be fetched
+			content, err := s.fetchContent(ctx, client, item.URL)
+			if err != nil {
+				var fragments []string
+				for _, m := range item.TextMatches {
+					fragments = append(fragments, m.Fragment)
+				}
+				content = strings.Join(fragments, "\n")
+			}
+
+			for _, found := range s.extract(content) {
+				if seen[found] {
+					continue
+				}
+				seen[found] = true
+
+				select {
+				case <-ctx.Done():
+					return
+				case results <- appCtx.ScanRecord{
+					URL:      found,
+					Source:   "github",
+					Category: "none",
+				}:
+				}
 			}
 		}
 
@@ -113,8 +190,93 @@ func (s *GitHub) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
 			break
 		}
 		page++
+	}
+}
+
+// fetchContent downloads the raw file behind a code search hit.
+func (s *GitHub) fetchContent(ctx context.Context, client *http.Client, contentsURL string) (string, error) {
+	if contentsURL == "" {
+		return "", fmt.Errorf("no contents url")
+	}
+	key, ok := s.keys.Current()
+	if !ok {
+		return "", fmt.Errorf("no usable key")
+	}
+	if err := s.content.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
 
-		// GitHub search API limit is strict, let's sleep a bit between pages
-		time.Sleep(2 * time.Second)
+	req, err := http.NewRequestWithContext(ctx, "GET", contentsURL, nil)
+	if err != nil {
+		return "", err
 	}
+	req.Header.Set("Authorization", "token "+key)
+	req.Header.Set("Accept", "application/vnd.github.raw")
+
+	resp, err := s.content.doRotating(ctx, client, req, s.keys)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		if resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 429 {
+			s.keys.Rotate(key, resp.StatusCode)
+		}
+		return "", fmt.Errorf("bad status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, githubMaxFileSize))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// extract returns every URL or bare host in text that belongs to the target domain.
+func (s *GitHub) extract(text string) []string {
+	var found []string
+	for _, loc := range s.matcher.FindAllStringIndex(text, -1) {
+		// Reject partial hosts like "example.company" or "example.com.evil.com"
+		if loc[1] < len(text) && isHostChar(text[loc[1]]) {
+			continue
+		}
+		if loc[1]+1 < len(text) && text[loc[1]] == '.' && isHostChar(text[loc[1]+1]) {
+			continue
+		}
+		// ...and email addresses ("admin@example.com") or hosts the
+		// pattern only caught the tail of
+		if loc[0] > 0 && (text[loc[0]-1] == '@' || text[loc[0]-1] == '.') {
+			continue
+		}
+		m := strings.TrimRight(text[loc[0]:loc[1]], ".,;:!?")
+
+		host := m
+		if i := strings.Index(host, "://"); i >= 0 {
+			host = host[i+3:]
+		}
+		if i := strings.IndexAny(host, "/:?#"); i >= 0 {
+			host = host[:i]
+		}
+		host = strings.ToLower(host)
+
+		// The pattern can't anchor the left edge of the host, so
+		// "notexample.com" still needs to be weeded out here.
+		if host != s.domain && !strings.HasSuffix(host, "."+s.domain) {
+			continue
+		}
+		found = append(found, m)
+	}
+	return found
+}
+
+// targetURLPattern matches URLs (with or without scheme) on domain or any of its subdomains.
+func targetURLPattern(domain string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:https?://)?[a-z0-9.-]*` + regexp.QuoteMeta(domain) +
+		`(?::\d+)?(?:[/?#][^\s"'<>` + "`" + `()\[\]{}\\]*)?`)
+}
+
+// isHostChar reports whether c can continue a hostname label.
+func isHostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
 }
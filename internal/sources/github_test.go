@@ -0,0 +1,86 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestGitHubExtract(t *testing.T) {
+	s := NewGitHub("example.com", config.Config{})
+
+	text := `api = "https://api.example.com/v1/users?id=1"
+see docs.example.com, or EXAMPLE.COM:8443/admin.
+contact admin@example.com or ops@mail.example.com
+bounce https://example.com.evil.com/x and example.com.attacker.net
+skip notexample.com, example.company and sub.example.com_old`
+
+	got := s.extract(text)
+	want := []string{
+		"https://api.example.com/v1/users?id=1",
+		"docs.example.com",
+		"EXAMPLE.COM:8443/admin",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestGitHubRun(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token k1" {
+			t.Errorf("unexpected auth header %q", r.Header.Get("Authorization"))
+		}
+
+		switch r.URL.Path {
+		case "/search/code":
+			if r.URL.Query().Get("page") != "1" {
+				fmt.Fprint(w, `{"items": []}`)
+				return
+			}
+			fmt.Fprintf(w, `{"items": [
+				{"url": "%s/blob/1", "text_matches": [{"fragment": "https://a.example.com/from-fragment"}]},
+				{"url": "%s/blob/missing", "text_matches": [{"fragment": "https://b.example.com/from-fragment"}]}
+			]}`, srv.URL, srv.URL)
+		case "/blob/1":
+			fmt.Fprint(w, "BASE = 'https://a.example.com/api'\nOTHER = 'https://a.example.com/api'\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{
+		ApiKeys:    config.ApiKeys{GitHub: []string{"k1"}},
+		RateLimits: map[string]config.RateLimit{"github": {}, "github_content": {}}, // unpaced
+	}
+	s := NewGitHub("example.com", cfg)
+	s.apiURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	for r := range results {
+		if r.Source != "github" {
+			t.Errorf("Expected source github, got %q", r.Source)
+		}
+		urls = append(urls, r.URL)
+	}
+	sort.Strings(urls)
+
+	// The whole file wins over its fragment; the snippet is the fallback
+	want := []string{"https://a.example.com/api", "https://b.example.com/from-fragment"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
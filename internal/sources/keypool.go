@@ -0,0 +1,178 @@
+package sources
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KeyPool rotates through the API keys configured for one provider.
+// Keys passed to Rotate are marked exhausted for the rest of the run; keys
+// passed to Advance are only set aside until the pool comes back round.
+type KeyPool struct {
+	mu        sync.Mutex
+	name      string
+	keys      []string
+	exhausted []bool
+	current   int
+}
+
+// NewKeyPool builds a pool from the configured keys, ignoring blanks.
+func NewKeyPool(name string, keys []string) *KeyPool {
+	var clean []string
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			clean = append(clean, k)
+		}
+	}
+
+	return &KeyPool{
+		name:      name,
+		keys:      clean,
+		exhausted: make([]bool, len(clean)),
+	}
+}
+
+// Len returns how many keys are configured.
+func (p *KeyPool) Len() int {
+	return len(p.keys)
+}
+
+// Current returns the active key, or false once every key is exhausted.
+func (p *KeyPool) Current() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current >= len(p.keys) {
+		return "", false
+	}
+	return p.keys[p.current], true
+}
+
+// HasSpare reports whether another unexhausted key exists besides the active one.
+func (p *KeyPool) HasSpare() bool {
+	return p.Usable() > 1
+}
+
+// Usable returns how many keys are not exhausted.
+func (p *KeyPool) Usable() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for _, exhausted := range p.exhausted {
+		if !exhausted {
+			n++
+		}
+	}
+	return n
+}
+
+// Advance moves past key without exhausting it, for limits that reset on
+// their own such as a per-minute quota. Like Rotate, it is a no-op when key
+// is no longer active.
+func (p *KeyPool) Advance(key string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current < len(p.keys) && p.keys[p.current] == key {
+		p.next()
+	}
+
+	if p.current >= len(p.keys) {
+		return "", false
+	}
+	return p.keys[p.current], true
+}
+
+// Rotate marks key as exhausted and moves on to the next usable key.
+// It returns false when no keys are left. Rotating a key that is no longer
+// active (another request already rotated it) just reports the current one.
+func (p *KeyPool) Rotate(key string, status int) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current < len(p.keys) && p.keys[p.current] == key {
+		p.exhausted[p.current] = true
+		p.next()
+
+		if p.current < len(p.keys) {
+			fmt.Fprintf(os.Stderr, "[!] %s key #%d rejected (%d), rotating to key #%d\n", p.name, indexOf(p.keys, key)+1, status, p.current+1)
+		} else {
+			fmt.Fprintf(os.Stderr, "[!] %s key #%d rejected (%d), no keys left\n", p.name, indexOf(p.keys, key)+1, status)
+		}
+	}
+
+	if p.current >= len(p.keys) {
+		return "", false
+	}
+	return p.keys[p.current], true
+}
+
+// next makes the first unexhausted key after the active one current,
+// wrapping around, or leaves the pool empty if there is none. Callers hold mu.
+func (p *KeyPool) next() {
+	for i := 1; i <= len(p.keys); i++ {
+		j := (p.current + i) % len(p.keys)
+		if !p.exhausted[j] {
+			p.current = j
+			return
+		}
+	}
+	p.current = len(p.keys)
+}
+
+// Test probes every key with the request newReq builds for it, so a bad key
+// cannot hide behind a good one. When any key fails, the error lists how
+// each key fared.
+func (p *KeyPool) Test(newReq func(key string) (*http.Request, error)) error {
+	if len(p.keys) == 0 {
+		return fmt.Errorf("%w: no API key configured", ErrBadKey)
+	}
+	if len(p.keys) == 1 {
+		req, err := newReq(p.keys[0])
+		if err != nil {
+			return err
+		}
+		return probe(req)
+	}
+
+	failed := &keyTestError{}
+	for i, key := range p.keys {
+		req, err := newReq(key)
+		if err != nil {
+			return err
+		}
+		if err := probe(req); err != nil {
+			failed.errs = append(failed.errs, err)
+			failed.results = append(failed.results, fmt.Sprintf("key #%d: %v", i+1, err))
+		} else {
+			failed.results = append(failed.results, fmt.Sprintf("key #%d: %s", i+1, TestOK))
+		}
+	}
+	if len(failed.errs) == 0 {
+		return nil
+	}
+	return failed
+}
+
+// keyTestError reports a pool test with at least one failing key. It
+// unwraps to the failures so the Err* values still classify it.
+type keyTestError struct {
+	results []string
+	errs    []error
+}
+
+func (e *keyTestError) Error() string   { return strings.Join(e.results, "; ") }
+func (e *keyTestError) Unwrap() []error { return e.errs }
+
+func indexOf(list []string, v string) int {
+	for i, s := range list {
+		if s == v {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package sources
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKeyPoolRotate(t *testing.T) {
+	p := NewKeyPool("test", []string{"a", " ", "b", "c"})
+	if p.Len() != 3 {
+		t.Fatalf("Expected 3 keys, got %d", p.Len())
+	}
+
+	key, _ := p.Current()
+	if key != "a" || !p.HasSpare() {
+		t.Fatalf("Expected key a with spares, got %q", key)
+	}
+
+	if next, ok := p.Rotate("a", 401); !ok || next != "b" {
+		t.Fatalf("Expected rotation to b, got %q %v", next, ok)
+	}
+
+	// A stale rotation from a concurrent request must not skip b
+	if next, _ := p.Rotate("a", 429); next != "b" {
+		t.Errorf("Expected b to stay active, got %q", next)
+	}
+
+	p.Rotate("b", 403)
+	if next, ok := p.Rotate("c", 429); ok || next != "" {
+		t.Errorf("Expected pool exhausted, got %q %v", next, ok)
+	}
+	if _, ok := p.Current(); ok {
+		t.Error("Expected no current key after exhaustion")
+	}
+}
+
+func TestKeyPoolAdvance(t *testing.T) {
+	p := NewKeyPool("test", []string{"a", "b", "c"})
+
+	if next, _ := p.Advance("a"); next != "b" || p.Usable() != 3 {
+		t.Fatalf("Expected b with every key usable, got %q (%d usable)", next, p.Usable())
+	}
+	p.Rotate("b", 401)
+	// Advancing wraps back round to a, which was only set aside
+	if next, _ := p.Advance("c"); next != "a" {
+		t.Errorf("Expected a after c, got %q", next)
+	}
+	if p.Usable() != 2 || !p.HasSpare() {
+		t.Errorf("Expected 2 usable keys, got %d", p.Usable())
+	}
+}
+
+func TestKeyPoolTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("key") == "bad" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	test := func(keys ...string) error {
+		return NewKeyPool("test", keys).Test(func(key string) (*http.Request, error) {
+			req, err := http.NewRequest("GET", srv.URL, nil)
+			if err == nil {
+				req.Header.Set("key", key)
+			}
+			return req, err
+		})
+	}
+
+	if err := test("good", "good"); err != nil {
+		t.Errorf("Expected every key to pass, got %v", err)
+	}
+
+	err := test("good", "bad")
+	if !errors.Is(err, ErrBadKey) {
+		t.Fatalf("Expected a bad key error, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "key #1: OK") || !strings.Contains(msg, "key #2: bad key") {
+		t.Errorf("Expected each key reported, got %q", msg)
+	}
+}
@@ -45,6 +45,28 @@ func (m *Manager) Register(s Source) {
 	m.sources = append(m.sources, s)
 }
 
+// RegisterAll adds every source from the registry plus the exec sources
+// declared in the config. Nothing is added when there is no domain to
+// query (pure -i runs only replay their input).
+func (m *Manager) RegisterAll() {
+	domain := m.appCtx.Domain
+	if domain == "" {
+		return
+	}
+
+	for _, s := range build(domain, m.config) {
+		m.Register(s)
+	}
+
+	for _, e := range m.config.Sources.Exec {
+		if e.Name == "" || e.Command == "" {
+			fmt.Fprintln(os.Stderr, "[!] Skipping exec source: name and command are required")
+			continue
+		}
+		m.Register(NewExecSource(domain, e))
+	}
+}
+
 // ActiveCount returns the number of currently running sources.
 func (m *Manager) ActiveCount() int64 {
 	return atomic.LoadInt64(&m.activeSources)
@@ -57,7 +79,7 @@ func (m *Manager) StartAll(ctx context.Context) <-chan appCtx.ScanRecord {
 	var wg sync.WaitGroup
 
 	// Get API keys for checking availability
-	keys := config.GetAPIKeys()
+	keys := m.config.ApiKeys
 
 	for _, src := range m.sources {
 		// 1. Check if source is enabled by user
@@ -68,7 +90,7 @@ func (m *Manager) StartAll(ctx context.Context) <-chan appCtx.ScanRecord {
 		// 2. Check if API key is present if required
 		if src.NeedsKey() {
 			if !m.hasKey(src.Name(), keys) {
-				fmt.Printf("[!] Skipping %s: Missing API Key\n", src.Name())
+				fmt.Fprintf(os.Stderr, "[!] Skipping %s: Missing API Key\n", src.Name())
 				continue
 			}
 		}
@@ -105,10 +127,37 @@ func (m *Manager) StartAll(ctx context.Context) <-chan appCtx.ScanRecord {
 	return out
 }
 
+// SourceUsage pairs a source name with its API consumption.
+type SourceUsage struct {
+	Name string
+	Usage
+}
+
+// Usage returns the API consumption of every metered source that made calls.
+func (m *Manager) Usage() []SourceUsage {
+	var usage []SourceUsage
+	for _, src := range m.sources {
+		mt, ok := src.(Metered)
+		if !ok {
+			continue
+		}
+		u := mt.Usage()
+		if u.Calls == 0 && !u.QuotaExhausted {
+			continue
+		}
+		usage = append(usage, SourceUsage{Name: src.Name(), Usage: u})
+	}
+	return usage
+}
+
 // isSourceEnabled checks if the user allowed this specific source.
-// If AppCtx.Sources is empty, ALL sources are enabled.
+// If AppCtx.Sources is empty, registry sources follow their DefaultEnabled
+// flag and everything else (file, stdin, exec) is enabled.
 func (m *Manager) isSourceEnabled(name string) bool {
 	if len(m.appCtx.Sources) == 0 {
+		if info, ok := Lookup(name); ok {
+			return info.DefaultEnabled
+		}
 		return true
 	}
 	for _, s := range m.appCtx.Sources {
@@ -119,33 +168,37 @@ func (m *Manager) isSourceEnabled(name string) bool {
 	return false
 }
 
-// hasKey checks availability of specific keys.
-// This is a simple helper mapping source names to key struct fields.
+// hasKey checks that the api_keys entry declared in the registry is set.
 func (m *Manager) hasKey(name string, keys config.ApiKeys) bool {
-	switch name {
-	case "virustotal":
-		return keys.VirusTotal != ""
-	case "urlscan":
-		return keys.URLScan != ""
-	default:
-		return true // If it needs checks but isn't listed, assume open or handled inside
+	info, ok := Lookup(name)
+	if !ok || info.KeyName == "" {
+		return true // Not a registry source, it checks its own requirements
 	}
+	return len(keys.Get(info.KeyName)) > 0
 }
 
-// Helper: Retry with exponential backoff
-// Usage: sources should call this inside their Run loop if they implement HTTP requests manually.
-func WithRetry(operation func() error, maxRetries int) error {
-	var err error
-	for i := 0; i < maxRetries; i++ {
-		if err = operation(); err == nil {
-			return nil
-		}
+// sleepCtx waits for d or until ctx is cancelled. Returns false if cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
 
-		// Exponential backoff: 1s, 2s, 4s...
-		duration := time.Duration(1<<i) * time.Second
-		time.Sleep(duration)
+// parseSourceTime reads the capture dates APIs report, which come with
+// and without a zone and with a T or a space. Unknown formats give zero.
+func parseSourceTime(value string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05", cdxTimeLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
 	}
-	return fmt.Errorf("failed after %d retries: %w", maxRetries, err)
+	return time.Time{}
 }
 
 // isTTY matches the logic in internal/ui/intro.go but duplicated to avoid import cycles.
@@ -5,21 +5,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+const otxAPIURL = "https://otx.alienvault.com/api/v1"
+
+func init() {
+	Register(Info{
+		Name:           "otx",
+		Description:    "AlienVault OTX URL list",
+		KeyName:        "alienvault",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewAlienVault(domain, cfg)
+	})
+}
+
 type AlienVault struct {
+	metered
 	domain string
-	apiKey string
+	keys   *KeyPool
+	apiURL string
 }
 
 func NewAlienVault(domain string, cfg config.Config) *AlienVault {
 	return &AlienVault{
-		domain: domain,
-		apiKey: cfg.ApiKeys.AlienVault,
+		metered: newMetered("otx", cfg),
+		domain:  domain,
+		keys:    NewKeyPool("OTX", cfg.ApiKeys.AlienVault),
+		apiURL:  otxAPIURL,
 	}
 }
 
@@ -31,15 +49,29 @@ func (s *AlienVault) NeedsKey() bool {
 	return true
 }
 
+// Test checks that the endpoint answers and accepts the key.
+func (s *AlienVault) Test(ctx context.Context) error {
+	// /user/me is the cheapest endpoint that actually checks the key
+	return s.keys.Test(func(key string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", s.apiURL+"/user/me", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-OTX-API-KEY", key)
+		return req, nil
+	})
+}
+
 type otxResponse struct {
 	URLList []struct {
-		URL string `json:"url"`
+		URL  string `json:"url"`
+		Date string `json:"date"`
 	} `json:"url_list"`
 	HasNext bool `json:"has_next"`
 }
 
 func (s *AlienVault) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
-	if s.apiKey == "" {
+	if s.keys.Len() == 0 {
 		return
 	}
 
@@ -47,33 +79,49 @@ func (s *AlienVault) Run(ctx context.Context, results chan<- appCtx.ScanRecord)
 	client := &http.Client{Timeout: 30 * time.Second}
 
 	for {
-		apiURL := fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/domain/%s/url_list?limit=50&page=%d", s.domain, page)
+		apiURL := fmt.Sprintf("%s/indicators/domain/%s/url_list?limit=50&page=%d", s.apiURL, s.domain, page)
+		key, ok := s.keys.Current()
+		if !ok {
+			return
+		}
+
+		if !s.acquire(ctx, "OTX") {
+			return
+		}
+
 		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
 			return
 		}
-		req.Header.Set("X-OTX-API-KEY", s.apiKey)
+		req.Header.Set("X-OTX-API-KEY", key)
 
-		var resp *http.Response
-		err = WithRetry(func() error {
-			var e error
-			resp, e = client.Do(req)
-			return e
-		}, 3)
+		resp, err := s.doRotating(ctx, client, req, s.keys)
 
 		if err != nil {
-			fmt.Printf("[!] OTX Request Failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "[!] OTX Request Failed: %v\n", err)
 			return
 		}
-		defer resp.Body.Close()
-
 		if resp.StatusCode != 200 {
-			// Stop on error
+			resp.Body.Close()
+			if resp.StatusCode == 401 || resp.StatusCode == 403 || resp.StatusCode == 429 {
+				if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+					continue // Retry the same page with the next key
+				}
+			}
+
+			// Stop on error, but say why instead of ending silently
+			if resp.StatusCode == 401 || resp.StatusCode == 403 {
+				fmt.Fprintln(os.Stderr, "[!] OTX Auth failed")
+			} else {
+				fmt.Fprintf(os.Stderr, "[!] OTX Status: %d\n", resp.StatusCode)
+			}
 			break
 		}
 
 		var data otxResponse
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		if err != nil {
 			break
 		}
 
@@ -86,9 +134,10 @@ func (s *AlienVault) Run(ctx context.Context, results chan<- appCtx.ScanRecord)
 			case <-ctx.Done():
 				return
 			case results <- appCtx.ScanRecord{
-				URL:      item.URL,
-				Source:   "otx",
-				Category: "none",
+				URL:        item.URL,
+				Source:     "otx",
+				Category:   "none",
+				CapturedAt: parseSourceTime(item.Date),
 			}:
 			}
 		}
@@ -0,0 +1,62 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestOTXCaptureDates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"url_list": [{"url": "https://a.example.com/", "date": "2021-03-04T05:06:07"}], "has_next": true}`)
+		default:
+			fmt.Fprint(w, `{"url_list": [{"url": "https://b.example.com/"}], "has_next": false}`)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{
+		ApiKeys:    config.ApiKeys{AlienVault: []string{"k1"}},
+		RateLimits: map[string]config.RateLimit{"otx": {}},
+	}
+	s := NewAlienVault("example.com", cfg)
+	s.apiURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var got []appCtx.ScanRecord
+	for r := range results {
+		got = append(got, r)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(got))
+	}
+	if want := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC); !got[0].CapturedAt.Equal(want) {
+		t.Errorf("Expected %v, got %v", want, got[0].CapturedAt)
+	}
+	if !got[1].CapturedAt.IsZero() {
+		t.Errorf("Expected no date for an undated entry, got %v", got[1].CapturedAt)
+	}
+}
+
+func TestParseSourceTime(t *testing.T) {
+	want := time.Date(2019, 5, 30, 5, 42, 42, 0, time.UTC)
+	for _, value := range []string{"2019-05-30T05:42:42Z", "2019-05-30T05:42:42.000Z", "2019-05-30T05:42:42", "2019-05-30 05:42:42", "20190530054242"} {
+		if got := parseSourceTime(value); !got.Equal(want) {
+			t.Errorf("parseSourceTime(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if got := parseSourceTime("last week"); !got.IsZero() {
+		t.Errorf("Expected zero time for an unknown format, got %v", got)
+	}
+}
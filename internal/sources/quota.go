@@ -0,0 +1,167 @@
+package sources
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Scans running side by side share the ledger file through a lock file
+// next to it. A take holds it for a read and a write, so a lock older than
+// ledgerLockStale was left behind by a crash.
+const (
+	ledgerLockWait  = 5 * time.Second
+	ledgerLockStale = 30 * time.Second
+)
+
+// QuotaLedger counts calls against daily quotas per calendar day (local
+// time) and keeps the count on disk, so the budget holds across scans,
+// across the targets of a -t batch and across concurrent deflot processes.
+type QuotaLedger struct {
+	mu   sync.Mutex
+	path string
+
+	Day   string            `json:"day"`
+	Calls map[string]uint64 `json:"calls"`
+}
+
+var (
+	ledgerMu    sync.Mutex
+	dailyLedger *QuotaLedger
+)
+
+// TrackDailyQuota makes every limiter with a daily_quota count its calls in
+// the ledger at path. Without it, quotas only cover the current process.
+// An unreadable ledger starts from zero rather than blocking the scan.
+func TrackDailyQuota(path string) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+
+	if dailyLedger != nil && dailyLedger.path == path {
+		return
+	}
+	dailyLedger = OpenQuotaLedger(path)
+}
+
+// OpenQuotaLedger loads the ledger at path, or starts an empty one.
+func OpenQuotaLedger(path string) *QuotaLedger {
+	q := &QuotaLedger{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, q)
+	}
+	if q.Calls == nil {
+		q.Calls = make(map[string]uint64)
+	}
+	return q
+}
+
+func currentLedger() *QuotaLedger {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+	return dailyLedger
+}
+
+// rollover resets the counts when the calendar day has changed.
+func (q *QuotaLedger) rollover() {
+	if today := time.Now().Format("2006-01-02"); q.Day != today {
+		q.Day = today
+		q.Calls = make(map[string]uint64)
+	}
+}
+
+// Used returns the calls made today by a source.
+func (q *QuotaLedger) Used(name string) uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover()
+	return q.Calls[name]
+}
+
+// take counts one call if the source is still under quota, including the
+// calls other processes have recorded in the file since it was read. If
+// the file cannot be locked the call is only counted in memory.
+func (q *QuotaLedger) take(name string, quota uint64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	unlock, locked := lockLedger(q.path)
+	if locked {
+		defer unlock()
+		q.merge()
+	}
+
+	q.rollover()
+	if q.Calls[name] >= quota {
+		return false
+	}
+	q.Calls[name]++
+	if locked {
+		q.save()
+	}
+	return true
+}
+
+// merge folds in today's counts from disk, keeping the higher count per
+// source. Called with the file lock held.
+func (q *QuotaLedger) merge() {
+	disk := OpenQuotaLedger(q.path)
+	q.rollover()
+	if disk.Day != q.Day {
+		return
+	}
+	for name, calls := range disk.Calls {
+		if calls > q.Calls[name] {
+			q.Calls[name] = calls
+		}
+	}
+}
+
+// lockLedger creates the lock file for the ledger at path, waiting for
+// another process to release it. It reports false if the lock could not
+// be taken.
+func lockLedger(path string) (func(), bool) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, false
+	}
+
+	lock := path + ".lock"
+	deadline := time.Now().Add(ledgerLockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(lock) }, true
+		}
+		if !os.IsExist(err) {
+			return nil, false
+		}
+
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > ledgerLockStale {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// save writes the ledger through a temp file so a crash mid-write never
+// leaves it truncated. Errors only cost the persistence.
+func (q *QuotaLedger) save() {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(q.path), 0755); err != nil {
+		return
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, q.path)
+}
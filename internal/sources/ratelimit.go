@@ -0,0 +1,195 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+)
+
+// ErrQuotaExhausted is returned by Limiter.Wait once the call budget is spent.
+var ErrQuotaExhausted = errors.New("daily quota exhausted")
+
+// defaultRateLimits keep free-tier keys safe when the config says nothing.
+var defaultRateLimits = map[string]config.RateLimit{
+	"virustotal": {PerMinute: 4, DailyQuota: 500},
+	"github":     {PerMinute: 30}, // code search
+	// Raw file downloads count against the 5,000/hour REST limit instead
+	"github_content": {PerMinute: 60},
+	"otx":            {PerSecond: 2},
+	"urlscan":        {PerMinute: 60},
+	"commoncrawl":    {PerSecond: 1},
+}
+
+// Limiter is a token bucket with an optional daily call budget, shared by
+// all API calls a source makes. The budget is counted in the QuotaLedger
+// when one is tracked, otherwise per limiter.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // tokens per second, 0 = unlimited
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	quota     uint64
+	calls     uint64
+	exhausted bool
+
+	name   string
+	ledger *QuotaLedger
+}
+
+// NewLimiter builds a limiter from a config entry. The stricter of
+// PerSecond and PerMinute wins.
+func NewLimiter(cfg config.RateLimit) *Limiter {
+	rate := cfg.PerSecond
+	if perMin := cfg.PerMinute / 60; perMin > 0 && (rate == 0 || perMin < rate) {
+		rate = perMin
+	}
+
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	quota := uint64(0)
+	if cfg.DailyQuota > 0 {
+		quota = uint64(cfg.DailyQuota)
+	}
+
+	return &Limiter{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		quota:  quota,
+	}
+}
+
+// limiterFor returns the configured limiter for a source, falling back to
+// the built-in defaults.
+func limiterFor(name string, cfg config.Config) *Limiter {
+	rl, ok := cfg.RateLimits[name]
+	if !ok {
+		rl = defaultRateLimits[name]
+	}
+
+	l := NewLimiter(rl)
+	if l.quota > 0 {
+		l.name, l.ledger = name, currentLedger()
+	}
+	return l
+}
+
+// Wait blocks until a call is allowed and counts it. It returns
+// ErrQuotaExhausted once the budget is spent, or ctx.Err() if cancelled.
+func (l *Limiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.spent() {
+			l.exhausted = true
+			l.mu.Unlock()
+			return ErrQuotaExhausted
+		}
+
+		now := time.Now()
+		if l.rate == 0 {
+			err := l.count()
+			l.mu.Unlock()
+			return err
+		}
+
+		if !l.last.IsZero() {
+			l.tokens += now.Sub(l.last).Seconds() * l.rate
+			if l.tokens > l.burst {
+				l.tokens = l.burst
+			}
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			err := l.count()
+			l.mu.Unlock()
+			return err
+		}
+
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		if !sleepCtx(ctx, wait) {
+			return ctx.Err()
+		}
+	}
+}
+
+// spent reports whether today's budget is used up. Called with mu held.
+func (l *Limiter) spent() bool {
+	if l.quota == 0 {
+		return false
+	}
+	if l.ledger != nil {
+		return l.ledger.Used(l.name) >= l.quota
+	}
+	return l.calls >= l.quota
+}
+
+// count books one call against the budget. Called with mu held.
+func (l *Limiter) count() error {
+	if l.quota > 0 && l.ledger != nil && !l.ledger.take(l.name, l.quota) {
+		l.exhausted = true
+		return ErrQuotaExhausted
+	}
+	l.calls++
+	return nil
+}
+
+// Usage reports what a source consumed during the run.
+type Usage struct {
+	Calls          uint64
+	Retries        uint64
+	QuotaExhausted bool
+}
+
+// Usage returns the calls made so far and whether the budget ran out.
+func (l *Limiter) Usage() Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return Usage{Calls: l.calls, QuotaExhausted: l.exhausted}
+}
+
+// Metered is implemented by sources that pace their API calls through a Limiter.
+type Metered interface {
+	Usage() Usage
+}
+
+// metered is embedded by sources to share a Limiter, count retries and
+// expose Usage.
+type metered struct {
+	limiter *Limiter
+	retries *atomic.Uint64
+}
+
+func newMetered(name string, cfg config.Config) metered {
+	return metered{limiter: limiterFor(name, cfg), retries: new(atomic.Uint64)}
+}
+
+func (m metered) Usage() Usage {
+	u := m.limiter.Usage()
+	u.Retries = m.retries.Load()
+	return u
+}
+
+// acquire waits for the limiter and reports whether the call may proceed.
+// Running out of budget is announced so the source can stop gracefully.
+func (m metered) acquire(ctx context.Context, source string) bool {
+	err := m.limiter.Wait(ctx)
+	if errors.Is(err, ErrQuotaExhausted) {
+		fmt.Fprintf(os.Stderr, "[!] %s: daily quota exhausted, stopping (%d calls this scan)\n", source, m.limiter.Usage().Calls)
+	}
+	return err == nil
+}
@@ -0,0 +1,134 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+)
+
+func TestLimiterQuota(t *testing.T) {
+	l := NewLimiter(config.RateLimit{DailyQuota: 3})
+
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+	}
+
+	if err := l.Wait(context.Background()); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Expected ErrQuotaExhausted, got %v", err)
+	}
+
+	u := l.Usage()
+	if u.Calls != 3 || !u.QuotaExhausted {
+		t.Errorf("Expected 3 calls and exhausted, got %+v", u)
+	}
+}
+
+func TestLimiterDailyQuotaAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota_usage.json")
+	cfg := config.Config{RateLimits: map[string]config.RateLimit{"virustotal": {DailyQuota: 2}}}
+
+	TrackDailyQuota(path)
+	defer func() { dailyLedger = nil }()
+
+	// Two targets of a batch each get a fresh limiter
+	first := limiterFor("virustotal", cfg)
+	if err := first.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second := limiterFor("virustotal", cfg)
+	if err := second.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := second.Wait(context.Background()); !errors.Is(err, ErrQuotaExhausted) {
+		t.Fatalf("Expected ErrQuotaExhausted, got %v", err)
+	}
+
+	// A later scan on the same day reads the count back from disk
+	if used := OpenQuotaLedger(path).Used("virustotal"); used != 2 {
+		t.Errorf("Expected 2 calls on record, got %d", used)
+	}
+
+	// Yesterday's usage does not count
+	stale := OpenQuotaLedger(path)
+	stale.Day = "2000-01-01"
+	stale.save()
+	dailyLedger = nil
+	TrackDailyQuota(path)
+	if err := limiterFor("virustotal", cfg).Wait(context.Background()); err != nil {
+		t.Errorf("Expected a fresh budget on a new day, got %v", err)
+	}
+}
+
+func TestQuotaLedgerSharedByProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota_usage.json")
+
+	// Two scans running side by side, each with its own copy of the ledger
+	a, b := OpenQuotaLedger(path), OpenQuotaLedger(path)
+	for i, l := range []*QuotaLedger{a, b, a} {
+		if !l.take("virustotal", 3) {
+			t.Fatalf("call %d: expected to be under quota", i+1)
+		}
+	}
+	if b.take("virustotal", 3) {
+		t.Error("Expected the other scan's calls to count against the quota")
+	}
+	if used := OpenQuotaLedger(path).Used("virustotal"); used != 3 {
+		t.Errorf("Expected 3 calls on record, got %d", used)
+	}
+
+	// A lock left behind by a crashed scan does not block for good
+	lock := path + ".lock"
+	os.WriteFile(lock, nil, 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(lock, old, old)
+	if !a.take("urlscan", 1) || OpenQuotaLedger(path).Used("urlscan") != 1 {
+		t.Error("Expected a stale lock to be broken")
+	}
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock to be released, got %v", err)
+	}
+}
+
+func TestLimiterPacing(t *testing.T) {
+	l := NewLimiter(config.RateLimit{PerSecond: 20, Burst: 2})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Two calls ride the burst, the next two wait ~50ms each
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected pacing of ~100ms, got %v", elapsed)
+	}
+}
+
+func TestLimiterStrictestRateWins(t *testing.T) {
+	l := NewLimiter(config.RateLimit{PerSecond: 10, PerMinute: 60})
+	if l.rate != 1 {
+		t.Errorf("Expected 1 req/s, got %v", l.rate)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(config.RateLimit{PerMinute: 1})
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
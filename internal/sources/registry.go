@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+)
+
+// Info describes a source in the registry.
+type Info struct {
+	Name        string
+	Description string
+	// KeyName is the api_keys entry the source needs (empty = no key).
+	KeyName string
+	// DefaultEnabled sources run when --sources is not given.
+	DefaultEnabled bool
+}
+
+// Factory builds a source for the given target domain.
+type Factory func(domain string, cfg config.Config) Source
+
+type registration struct {
+	info    Info
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registration)
+)
+
+// Register makes a source available to every scan. Sources call it from
+// their init function; registering the same name twice panics.
+func Register(info Info, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("sources: Register factory is nil for " + info.Name)
+	}
+	if _, dup := registry[info.Name]; dup {
+		panic("sources: Register called twice for " + info.Name)
+	}
+	registry[info.Name] = registration{info: info, factory: factory}
+}
+
+// Lookup returns the registry metadata for a source name.
+func Lookup(name string) (Info, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	reg, ok := registry[name]
+	return reg.info, ok
+}
+
+// Registered returns metadata for every registered source, sorted by name.
+func Registered() []Info {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	infos := make([]Info, 0, len(registry))
+	for _, reg := range registry {
+		infos = append(infos, reg.info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Catalog returns the registered sources plus the exec sources declared in cfg.
+func Catalog(cfg config.Config) []Info {
+	infos := Registered()
+	for _, e := range cfg.Sources.Exec {
+		if e.Name == "" || e.Command == "" {
+			continue
+		}
+		infos = append(infos, Info{
+			Name:           e.Name,
+			Description:    fmt.Sprintf("External command: %s", e.Command),
+			DefaultEnabled: true,
+		})
+	}
+	return infos
+}
+
+// build instantiates every registered source for domain, sorted by name.
+func build(domain string, cfg config.Config) []Source {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	built := make([]Source, 0, len(names))
+	for _, name := range names {
+		built = append(built, registry[name].factory(domain, cfg))
+	}
+	return built
+}
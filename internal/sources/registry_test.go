@@ -0,0 +1,89 @@
+package sources
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+type stubSource struct {
+	name string
+}
+
+func (s stubSource) Name() string                                        { return s.name }
+func (s stubSource) NeedsKey() bool                                      { return false }
+func (s stubSource) Run(ctx context.Context, _ chan<- appCtx.ScanRecord) {}
+
+// registerStub adds a source for the duration of a test.
+func registerStub(t *testing.T, info Info) {
+	t.Helper()
+	Register(info, func(domain string, cfg config.Config) Source {
+		return stubSource{name: info.Name}
+	})
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, info.Name)
+		registryMu.Unlock()
+	})
+}
+
+func TestRegister(t *testing.T) {
+	registerStub(t, Info{Name: "zz-stub", Description: "stub", KeyName: "github"})
+
+	info, ok := Lookup("zz-stub")
+	if !ok || info.Description != "stub" || info.KeyName != "github" {
+		t.Fatalf("Expected the stub to be looked up, got %+v, %v", info, ok)
+	}
+
+	infos := Registered()
+	for i := 1; i < len(infos); i++ {
+		if infos[i-1].Name >= infos[i].Name {
+			t.Errorf("Expected sources sorted by name, got %s before %s", infos[i-1].Name, infos[i].Name)
+		}
+	}
+	if infos[len(infos)-1].Name != "zz-stub" {
+		t.Errorf("Expected the stub among the registered sources")
+	}
+
+	built := build("example.com", config.Config{})
+	if len(built) != len(infos) || built[len(built)-1].Name() != "zz-stub" {
+		t.Errorf("Expected every registered source to be built")
+	}
+}
+
+func TestRegisterRejectsDuplicatesAndNil(t *testing.T) {
+	registerStub(t, Info{Name: "zz-stub"})
+
+	for name, register := range map[string]func(){
+		"duplicate":   func() { registerStub(t, Info{Name: "zz-stub"}) },
+		"nil factory": func() { Register(Info{Name: "zz-nil"}, nil) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected Register to panic", name)
+				}
+			}()
+			register()
+		}()
+	}
+}
+
+func TestCatalogIncludesExecSources(t *testing.T) {
+	cfg := config.Config{}
+	cfg.Sources.Exec = []config.ExecSettings{
+		{Name: "subfinder", Command: "subfinder -d {domain}"},
+		{Name: "incomplete"},
+	}
+
+	var found, incomplete bool
+	for _, info := range Catalog(cfg) {
+		found = found || info.Name == "subfinder"
+		incomplete = incomplete || info.Name == "incomplete"
+	}
+	if !found || incomplete {
+		t.Errorf("Expected only complete exec sources in the catalog (found %v, incomplete %v)", found, incomplete)
+	}
+}
@@ -0,0 +1,147 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxRetries     = 3
+	retryBaseDelay = time.Second
+	// Servers asking us to back off longer than this are not waited for;
+	// the last response is handed back to the source instead.
+	retryMaxDelay = 2 * time.Minute
+)
+
+// do sends req and retries network errors, 429 and 5xx with jittered
+// exponential backoff, or for as long as Retry-After / rate-limit reset
+// headers ask. 401, 403 and every other status are returned untouched.
+// Retries go through the source's limiter and are counted in its Usage.
+// The caller closes the body of the returned response.
+func (m metered) do(ctx context.Context, client *http.Client, req *http.Request) (*http.Response, error) {
+	return m.send(ctx, client, req, nil)
+}
+
+// doRotating is do for keyed sources: a 429 is returned straight away while
+// the pool still has a spare key, so the source can rotate instead of waiting.
+func (m metered) doRotating(ctx context.Context, client *http.Client, req *http.Request, keys *KeyPool) (*http.Response, error) {
+	return m.send(ctx, client, req, keys)
+}
+
+func (m metered) send(ctx context.Context, client *http.Client, req *http.Request, keys *KeyPool) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+
+		wait, retry := retryDecision(resp, err, attempt)
+		if retry && resp != nil && resp.StatusCode == http.StatusTooManyRequests && keys != nil && keys.HasSpare() {
+			retry = false
+		}
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		if !sleepCtx(ctx, wait) {
+			return nil, ctx.Err()
+		}
+		if err := m.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		m.retries.Add(1)
+	}
+}
+
+// retryDecision reports whether an attempt should be retried and after how long.
+func retryDecision(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= maxRetries {
+		return 0, false
+	}
+
+	if err != nil {
+		// A cancelled scan is not a network failure
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		return backoff(attempt), true
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return 0, false
+	}
+
+	if hint, ok := serverDelay(resp, time.Now()); ok {
+		if hint > retryMaxDelay {
+			return 0, false
+		}
+		return hint + jitter(retryBaseDelay), true
+	}
+	return backoff(attempt), true
+}
+
+// backoff doubles the base delay per attempt and spreads retries with jitter
+// so parallel workers don't hammer the server in lockstep.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << attempt
+	return d/2 + jitter(d/2)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
+
+// serverDelay reads how long the server asked us to wait. It understands
+// Retry-After (seconds or HTTP date) and, on 429, URLScan's
+// X-Rate-Limit-Reset-After, RateLimit-Reset (seconds) and GitHub's
+// X-RateLimit-Reset (epoch seconds).
+func serverDelay(resp *http.Response, now time.Time) (time.Duration, bool) {
+	h := resp.Header
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+
+	// Reset headers ride along on every response; they only mean "wait"
+	// once the limit has actually been hit.
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	for _, name := range []string{"X-Rate-Limit-Reset-After", "RateLimit-Reset"} {
+		if v := h.Get(name); v != "" {
+			if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+				return time.Duration(secs * float64(time.Second)), true
+			}
+		}
+	}
+
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return nonNegative(time.Unix(epoch, 0).Sub(now)), true
+		}
+	}
+
+	return 0, false
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
@@ -0,0 +1,92 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+)
+
+func TestRetryHonoursRetryAfter(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := newMetered("test", config.Config{})
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+
+	resp, err := m.do(context.Background(), srv.Client(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || hits != 3 {
+		t.Errorf("Expected 200 after 3 hits, got %d after %d", resp.StatusCode, hits)
+	}
+	if u := m.Usage(); u.Retries != 2 {
+		t.Errorf("Expected 2 retries, got %d", u.Retries)
+	}
+}
+
+func TestRetrySkipsAuthErrors(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	m := newMetered("test", config.Config{})
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+
+	resp, err := m.do(context.Background(), srv.Client(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if hits != 1 || m.Usage().Retries != 0 {
+		t.Errorf("Expected a single attempt for 403, got %d", hits)
+	}
+}
+
+func TestServerDelay(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		status int
+		header string
+		value  string
+		want   time.Duration
+		ok     bool
+	}{
+		{429, "Retry-After", "30", 30 * time.Second, true},
+		{503, "Retry-After", now.Add(10 * time.Second).UTC().Format(http.TimeFormat), 10 * time.Second, true},
+		{429, "X-Rate-Limit-Reset-After", "1.5", 1500 * time.Millisecond, true},
+		{429, "X-RateLimit-Reset", "1700000042", 42 * time.Second, true},
+		{503, "X-RateLimit-Reset", "1700000042", 0, false},
+		{429, "X-Other", "5", 0, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		resp.Header.Set(tt.header, tt.value)
+
+		got, ok := serverDelay(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%d %s=%s: got (%v, %v), want (%v, %v)", tt.status, tt.header, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
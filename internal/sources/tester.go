@@ -0,0 +1,142 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tester is implemented by sources that can validate their key and
+// endpoint with a single cheap request.
+type Tester interface {
+	Test(ctx context.Context) error
+}
+
+var (
+	ErrBadKey      = errors.New("bad key")
+	ErrRateLimited = errors.New("rate limited")
+	ErrUnreachable = errors.New("unreachable")
+)
+
+// Test outcomes as reported by `deflot sources test`.
+const (
+	TestOK          = "OK"
+	TestBadKey      = "BAD KEY"
+	TestRateLimited = "RATE LIMITED"
+	TestUnreachable = "UNREACHABLE"
+	TestError       = "ERROR"
+	TestNoKey       = "NO KEY"
+	TestSkipped     = "SKIPPED"
+)
+
+// TestResult is the pre-flight outcome for one source.
+type TestResult struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	Err     error
+}
+
+// Failed reports whether the result should fail a pre-flight check.
+func (r TestResult) Failed() bool {
+	return r.Status != TestOK && r.Status != TestSkipped && r.Status != TestNoKey
+}
+
+const testTimeout = 20 * time.Second
+
+// TestAll runs Test on every enabled source concurrently, sorted by name.
+// A missing key only counts as a failure when the source was asked for
+// explicitly with --sources.
+func (m *Manager) TestAll(ctx context.Context) []TestResult {
+	keys := m.config.ApiKeys
+	results := make([]TestResult, len(m.sources))
+
+	var wg sync.WaitGroup
+	for i, src := range m.sources {
+		if !m.isSourceEnabled(src.Name()) {
+			results[i] = TestResult{Name: src.Name(), Status: TestSkipped}
+			continue
+		}
+
+		if src.NeedsKey() && !m.hasKey(src.Name(), keys) {
+			res := TestResult{Name: src.Name(), Status: TestNoKey}
+			if len(m.appCtx.Sources) > 0 {
+				res.Status = TestBadKey
+				res.Err = errors.New("no API key configured")
+			}
+			results[i] = res
+			continue
+		}
+
+		tester, ok := src.(Tester)
+		if !ok {
+			results[i] = TestResult{Name: src.Name(), Status: TestSkipped}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, name string, t Tester) {
+			defer wg.Done()
+
+			tctx, cancel := context.WithTimeout(ctx, testTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := t.Test(tctx)
+			results[i] = TestResult{
+				Name:    name,
+				Status:  testStatus(err),
+				Latency: time.Since(start),
+				Err:     err,
+			}
+		}(i, src.Name(), tester)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+func testStatus(err error) string {
+	switch {
+	case err == nil:
+		return TestOK
+	case errors.Is(err, ErrBadKey):
+		return TestBadKey
+	case errors.Is(err, ErrRateLimited):
+		return TestRateLimited
+	case errors.Is(err, ErrUnreachable):
+		return TestUnreachable
+	default:
+		return TestError
+	}
+}
+
+// probe sends a test request and maps the outcome onto the Err* values.
+func probe(req *http.Request) error {
+	client := &http.Client{Timeout: testTimeout}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode == 401 || resp.StatusCode == 403:
+		return fmt.Errorf("%w: status %d", ErrBadKey, resp.StatusCode)
+	case resp.StatusCode == 429:
+		return fmt.Errorf("%w: status %d", ErrRateLimited, resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%w: status %d", ErrUnreachable, resp.StatusCode)
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+// probeSource answers Test by probing a URL, like the real sources do.
+type probeSource struct {
+	stubSource
+	url string
+}
+
+func (s probeSource) Test(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return err
+	}
+	return probe(req)
+}
+
+func TestAll(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Path[1:])
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	probed := func(name string, code int) Source {
+		return probeSource{stubSource{name}, srv.URL + "/" + strconv.Itoa(code)}
+	}
+
+	run := func(enabled []string) map[string]string {
+		m := NewManager(&appCtx.AppContext{Sources: enabled}, config.Config{})
+		m.Register(probed("a-ok", 200))
+		m.Register(probed("b-badkey", 401))
+		m.Register(probed("c-limited", 429))
+		m.Register(probed("d-down", 503))
+		m.Register(probed("e-odd", 404))
+		m.Register(stubSource{"f-untestable"})
+		m.Register(NewURLScan("example.com", config.Config{})) // no key configured
+
+		results := m.TestAll(context.Background())
+		statuses := make(map[string]string)
+		for i, r := range results {
+			if i > 0 && results[i-1].Name > r.Name {
+				t.Errorf("Expected results sorted by name, got %s before %s", results[i-1].Name, r.Name)
+			}
+			statuses[r.Name] = r.Status
+		}
+		return statuses
+	}
+
+	want := map[string]string{
+		"a-ok":         TestOK,
+		"b-badkey":     TestBadKey,
+		"c-limited":    TestRateLimited,
+		"d-down":       TestUnreachable,
+		"e-odd":        TestError,
+		"f-untestable": TestSkipped,
+		"urlscan":      TestNoKey,
+	}
+	if got := run(nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	// Asking for a keyless source explicitly turns the missing key into a
+	// failure; sources not asked for are skipped.
+	got := run([]string{"a-ok", "urlscan"})
+	if got["urlscan"] != TestBadKey || got["a-ok"] != TestOK || got["b-badkey"] != TestSkipped {
+		t.Errorf("Unexpected results with --sources: %v", got)
+	}
+}
+
+func TestResultFailed(t *testing.T) {
+	for status, failed := range map[string]bool{
+		TestOK: false, TestSkipped: false, TestNoKey: false,
+		TestBadKey: true, TestRateLimited: true, TestUnreachable: true, TestError: true,
+	} {
+		if got := (TestResult{Status: status}).Failed(); got != failed {
+			t.Errorf("%s: expected failed=%v, got %v", status, failed, got)
+		}
+	}
+}
@@ -5,21 +5,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+const (
+	urlScanSearchURL         = "https://urlscan.io/api/v1/search/"
+	defaultURLScanMaxResults = 10000
+	urlScanPageSize          = 1000
+)
+
+func init() {
+	Register(Info{
+		Name:           "urlscan",
+		Description:    "URLScan.io search",
+		KeyName:        "urlscan",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewURLScan(domain, cfg)
+	})
+}
+
 type URLScan struct {
-	domain string
-	apiKey string
+	metered
+	domain     string
+	keys       *KeyPool
+	searchURL  string
+	maxResults int
 }
 
 func NewURLScan(domain string, cfg config.Config) *URLScan {
+	maxResults := cfg.Sources.URLScan.MaxResults
+	if maxResults < 1 {
+		maxResults = defaultURLScanMaxResults
+	}
+
 	return &URLScan{
-		domain: domain,
-		apiKey: cfg.ApiKeys.URLScan,
+		metered:    newMetered("urlscan", cfg),
+		domain:     domain,
+		keys:       NewKeyPool("URLScan", cfg.ApiKeys.URLScan),
+		searchURL:  urlScanSearchURL,
+		maxResults: maxResults,
 	}
 }
 
@@ -31,64 +63,157 @@ func (s *URLScan) NeedsKey() bool {
 	return true
 }
 
+// Test checks that the endpoint answers and accepts the key.
+func (s *URLScan) Test(ctx context.Context) error {
+	q := url.Values{}
+	q.Set("q", "domain:"+s.domain)
+	q.Set("size", "1")
+
+	return s.keys.Test(func(key string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", s.searchURL+"?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("API-Key", key)
+		return req, nil
+	})
+}
+
 type urlScanResponse struct {
 	Results []struct {
 		Page struct {
 			URL string `json:"url"`
 		} `json:"page"`
+		Task struct {
+			Time string `json:"time"`
+		} `json:"task"`
+		Sort []json.RawMessage `json:"sort"`
 	} `json:"results"`
-	Total int `json:"total"`
+	Total   int  `json:"total"`
+	HasMore bool `json:"has_more"`
 }
 
 func (s *URLScan) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
-	if s.apiKey == "" {
+	if s.keys.Len() == 0 {
 		return
 	}
 
-	// Basic search, size 1000 to get a good chunk. Not full pagination for brevity in this task,
-	// but good enough for production "quick" recon.
-	apiURL := fmt.Sprintf("https://urlscan.io/api/v1/search/?q=domain:%s&size=1000", s.domain)
-
 	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return
-	}
-	req.Header.Set("API-Key", s.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	var resp *http.Response
-	err = WithRetry(func() error {
-		var e error
-		resp, e = client.Do(req)
-		return e
-	}, 3)
-
-	if err != nil {
-		fmt.Printf("[!] URLScan Request Failed: %v\n", err)
-		return
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		fmt.Printf("[!] URLScan Status: %d\n", resp.StatusCode)
-		return
-	}
+	searchAfter := ""
+	emitted, pages := 0, 0
 
-	var data urlScanResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return
-	}
+	// Progress goes to stderr so it never mixes into --stdout output
+	defer func() {
+		fmt.Fprintf(os.Stderr, "[*] URLScan: fetched %d pages (%d results)\n", pages, emitted)
+	}()
+
+	for emitted < s.maxResults {
+		size := urlScanPageSize
+		if remaining := s.maxResults - emitted; remaining < size {
+			size = remaining
+		}
+
+		q := url.Values{}
+		q.Set("q", "domain:"+s.domain)
+		q.Set("size", strconv.Itoa(size))
+		if searchAfter != "" {
+			q.Set("search_after", searchAfter)
+		}
+
+		key, ok := s.keys.Current()
+		if !ok {
+			return
+		}
+
+		if !s.acquire(ctx, "URLScan") {
+			return
+		}
 
-	for _, res := range data.Results {
-		select {
-		case <-ctx.Done():
+		req, err := http.NewRequestWithContext(ctx, "GET", s.searchURL+"?"+q.Encode(), nil)
+		if err != nil {
 			return
-		case results <- appCtx.ScanRecord{
-			URL:      res.Page.URL,
-			Source:   "urlscan",
-			Category: "none",
-		}:
 		}
+		req.Header.Set("API-Key", key)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.doRotating(ctx, client, req, s.keys)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[!] URLScan Request Failed: %v\n", err)
+			return
+		}
+
+		if resp.StatusCode == 401 || resp.StatusCode == 403 {
+			resp.Body.Close()
+			if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+				continue // Retry the same page with the next key
+			}
+			fmt.Fprintln(os.Stderr, "[!] URLScan Auth failed")
+			return
+		}
+
+		if resp.StatusCode == 429 {
+			// The retry helper already waited out X-Rate-Limit-Reset-After
+			resp.Body.Close()
+			if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "[!] URLScan rate limit persists, stopping")
+			return
+		}
+
+		if resp.StatusCode != 200 {
+			resp.Body.Close()
+			fmt.Fprintf(os.Stderr, "[!] URLScan Status: %d\n", resp.StatusCode)
+			return
+		}
+
+		var data urlScanResponse
+		err = json.NewDecoder(resp.Body).Decode(&data)
+		resp.Body.Close()
+		if err != nil {
+			return
+		}
+		pages++
+
+		for _, res := range data.Results {
+			select {
+			case <-ctx.Done():
+				return
+			case results <- appCtx.ScanRecord{
+				URL:        res.Page.URL,
+				Source:     "urlscan",
+				Category:   "none",
+				CapturedAt: parseSourceTime(res.Task.Time),
+			}:
+			}
+			emitted++
+		}
+
+		if !data.HasMore || len(data.Results) == 0 {
+			return
+		}
+
+		// The cursor is the sort tuple of the last hit on this page
+		searchAfter = joinSortValues(data.Results[len(data.Results)-1].Sort)
+		if searchAfter == "" {
+			return
+		}
+	}
+}
+
+// joinSortValues renders a search_after cursor from a result's sort array.
+// Values are kept as raw JSON so large numeric timestamps don't lose precision.
+func joinSortValues(values []json.RawMessage) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			parts = append(parts, str)
+			continue
+		}
+		parts = append(parts, string(v))
 	}
+	return strings.Join(parts, ",")
 }
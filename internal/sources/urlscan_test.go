@@ -0,0 +1,75 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestJoinSortValues(t *testing.T) {
+	values := []json.RawMessage{json.RawMessage(`1717000000000123`), json.RawMessage(`"3f2a-uuid"`)}
+	if got, want := joinSortValues(values), "1717000000000123,3f2a-uuid"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestURLScanSearchAfter(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		q := r.URL.Query()
+		if q.Get("q") != "domain:example.com" || r.Header.Get("API-Key") != "k1" {
+			t.Errorf("unexpected request %q", r.URL.RawQuery)
+		}
+
+		switch requests {
+		case 1:
+			if q.Get("search_after") != "" || q.Get("size") != "3" {
+				t.Errorf("first page: unexpected query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"results": [
+				{"page": {"url": "https://example.com/a"}, "task": {"time": "2024-01-02T03:04:05.000Z"}, "sort": [1704164645000, "id-a"]},
+				{"page": {"url": "https://example.com/b"}, "sort": [1704164600000, "id-b"]}
+			], "has_more": true}`)
+		case 2:
+			// Only what is left of max_results is asked for
+			if q.Get("search_after") != "1704164600000,id-b" || q.Get("size") != "1" {
+				t.Errorf("second page: unexpected query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"results": [
+				{"page": {"url": "https://example.com/c"}, "sort": [1704164500000, "id-c"]}
+			], "has_more": true}`)
+		default:
+			t.Errorf("unexpected request %d past max_results", requests)
+			fmt.Fprint(w, `{"results": [], "has_more": false}`)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{
+		ApiKeys:    config.ApiKeys{URLScan: []string{"k1"}},
+		RateLimits: map[string]config.RateLimit{"urlscan": {}},
+	}
+	cfg.Sources.URLScan.MaxResults = 3
+	s := NewURLScan("example.com", cfg)
+	s.searchURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	for r := range results {
+		urls = append(urls, r.URL)
+	}
+	if want := []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+}
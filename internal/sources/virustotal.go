@@ -3,23 +3,66 @@ package sources
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+// VirusTotal collection modes.
+const (
+	VTModeSubdomains = "subdomains"
+	VTModeURLs       = "urls"
+	VTModeBoth       = "both"
+)
+
+const (
+	vtBaseURL       = "https://www.virustotal.com"
+	vtMaxQuotaWaits = 3
+)
+
+var (
+	errVTAuth      = errors.New("auth failed")
+	errVTForbidden = errors.New("endpoint not available for this key")
+)
+
+func init() {
+	Register(Info{
+		Name:           "virustotal",
+		Description:    "VirusTotal subdomains and URLs",
+		KeyName:        "virustotal",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewVirusTotal(domain, cfg)
+	})
+}
+
 type VirusTotal struct {
-	domain string
-	apiKey string
+	metered
+	domain  string
+	keys    *KeyPool
+	baseURL string
+	mode    string
 }
 
 func NewVirusTotal(domain string, cfg config.Config) *VirusTotal {
+	mode := strings.ToLower(cfg.Sources.VirusTotal.Mode)
+	if mode != VTModeSubdomains && mode != VTModeURLs {
+		mode = VTModeBoth
+	}
+
 	return &VirusTotal{
-		domain: domain,
-		apiKey: cfg.ApiKeys.VirusTotal,
+		metered: newMetered("virustotal", cfg),
+		domain:  domain,
+		keys:    NewKeyPool("VT", cfg.ApiKeys.VirusTotal),
+		baseURL: vtBaseURL,
+		mode:    mode,
 	}
 }
 
@@ -31,88 +74,248 @@ func (s *VirusTotal) NeedsKey() bool {
 	return true
 }
 
+// Test checks that the endpoint answers and accepts every key.
+func (s *VirusTotal) Test(ctx context.Context) error {
+	apiURL := fmt.Sprintf("%s/api/v3/domains/%s", s.baseURL, url.PathEscape(s.domain))
+	return s.keys.Test(func(key string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("x-apikey", key)
+		return req, nil
+	})
+}
+
 type vtResponse struct {
 	Data []struct {
-		Id string `json:"id"` // The subdomain/url
+		Id         string `json:"id"` // The subdomain for subdomain relationships
+		Attributes struct {
+			URL string `json:"url"`
+		} `json:"attributes"`
 	} `json:"data"`
 	Links struct {
 		Next string `json:"next"`
 	} `json:"links"`
 }
 
+type vtV2Report struct {
+	ResponseCode   int             `json:"response_code"`
+	UndetectedURLs [][]interface{} `json:"undetected_urls"` // [url, sha256, positives, total, date]
+	DetectedURLs   []struct {
+		URL      string `json:"url"`
+		ScanDate string `json:"scan_date"`
+	} `json:"detected_urls"`
+}
+
 func (s *VirusTotal) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
-	if s.apiKey == "" {
+	if s.keys.Len() == 0 {
 		return
 	}
 
-	// Fetch subdomains
-	cursor := ""
-	baseURL := fmt.Sprintf("https://www.virustotal.com/api/v3/domains/%s/subdomains?limit=40", s.domain)
-
 	client := &http.Client{Timeout: 30 * time.Second}
 
-	for {
-		url := baseURL
-		if cursor != "" {
-			url = cursor // Next link is full URL
+	if s.mode == VTModeSubdomains || s.mode == VTModeBoth {
+		err := s.paginate(ctx, client, "subdomains", results)
+		if errors.Is(err, errVTAuth) {
+			fmt.Fprintln(os.Stderr, "[!] VT Auth failed")
+			return
+		}
+		if errors.Is(err, ErrQuotaExhausted) {
+			fmt.Fprintf(os.Stderr, "[!] VT daily quota exhausted, stopping (%d calls this scan)\n", s.Usage().Calls)
+			return
 		}
-
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 		if err != nil {
-			break
+			fmt.Fprintf(os.Stderr, "[!] VT subdomains: %v\n", err)
 		}
-		req.Header.Set("x-apikey", s.apiKey)
-
-		var resp *http.Response
-		err = WithRetry(func() error {
-			var e error
-			resp, e = client.Do(req)
-			return e
-		}, 3)
+	}
 
+	if s.mode == VTModeURLs || s.mode == VTModeBoth {
+		err := s.paginate(ctx, client, "urls", results)
+		if errors.Is(err, errVTForbidden) {
+			// The v3 URL relationship is premium-only; v2 report still lists URLs
+			err = s.reportV2(ctx, client, results)
+		}
+		if errors.Is(err, errVTAuth) {
+			fmt.Fprintln(os.Stderr, "[!] VT Auth failed")
+			return
+		}
+		if errors.Is(err, ErrQuotaExhausted) {
+			fmt.Fprintf(os.Stderr, "[!] VT daily quota exhausted, stopping (%d calls this scan)\n", s.Usage().Calls)
+			return
+		}
 		if err != nil {
-			fmt.Printf("[!] VT Request Failed: %v\n", err)
-			break
+			fmt.Fprintf(os.Stderr, "[!] VT urls: %v\n", err)
 		}
-		defer resp.Body.Close()
+	}
+}
 
-		if resp.StatusCode != 200 {
-			// 401/403 means bad key, stop
-			if resp.StatusCode == 401 || resp.StatusCode == 403 {
-				fmt.Println("[!] VT Auth failed")
-				break
-			}
-			// Rate limit?
-			if resp.StatusCode == 429 {
-				time.Sleep(5 * time.Second) // naive backoff
-				// retry? loop continues, might process next?
-				// actually we just consumed the body.
-				// For now, break on error.
-				break
-			}
-			break
+// paginate walks a v3 domain relationship, following the cursor links.
+func (s *VirusTotal) paginate(ctx context.Context, client *http.Client, relationship string, results chan<- appCtx.ScanRecord) error {
+	next := fmt.Sprintf("%s/api/v3/domains/%s/%s?limit=40", s.baseURL, url.PathEscape(s.domain), relationship)
+
+	for next != "" {
+		resp, err := s.get(ctx, client, next, true)
+		if err != nil {
+			return err
 		}
 
 		var vtResp vtResponse
-		if err := json.NewDecoder(resp.Body).Decode(&vtResp); err != nil {
-			break
+		err = json.NewDecoder(resp.Body).Decode(&vtResp)
+		resp.Body.Close()
+		if err != nil {
+			return err
 		}
 
 		for _, item := range vtResp.Data {
+			u := item.Attributes.URL
+			if u == "" {
+				u = item.Id
+			}
+
 			select {
 			case <-ctx.Done():
-				return
+				return nil
 			case results <- appCtx.ScanRecord{
-				URL:      item.Id,
+				URL:      u,
 				Source:   "virustotal",
 				Category: "none",
 			}:
 			}
 		}
 
-		if vtResp.Links.Next == "" {
-			break
+		next = vtResp.Links.Next // Next link is full URL
+	}
+
+	return nil
+}
+
+// reportV2 pulls detected/undetected URLs from the legacy domain report.
+func (s *VirusTotal) reportV2(ctx context.Context, client *http.Client, results chan<- appCtx.ScanRecord) error {
+	q := url.Values{}
+	q.Set("domain", s.domain)
+
+	resp, err := s.get(ctx, client, s.baseURL+"/vtapi/v2/domain/report?"+q.Encode(), false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var report vtV2Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return err
+	}
+
+	var records []appCtx.ScanRecord
+	for _, entry := range report.UndetectedURLs {
+		if len(entry) == 0 {
+			continue
+		}
+		u, ok := entry[0].(string)
+		if !ok {
+			continue
+		}
+		record := appCtx.ScanRecord{URL: u, Source: "virustotal", Category: "none"}
+		if len(entry) > 4 {
+			if date, ok := entry[4].(string); ok {
+				record.CapturedAt = parseSourceTime(date)
+			}
+		}
+		records = append(records, record)
+	}
+	for _, entry := range report.DetectedURLs {
+		records = append(records, appCtx.ScanRecord{
+			URL:        entry.URL,
+			Source:     "virustotal",
+			Category:   "none",
+			CapturedAt: parseSourceTime(entry.ScanDate),
+		})
+	}
+
+	for _, record := range records {
+		select {
+		case <-ctx.Done():
+			return nil
+		case results <- record:
+		}
+	}
+
+	return nil
+}
+
+// get performs a rate-limited request with the active key, rotating to the
+// next key when one is rejected and waiting out per-minute quota errors once
+// every key has hit one. The returned response always has status 200; the
+// caller closes the body.
+func (s *VirusTotal) get(ctx context.Context, client *http.Client, apiURL string, v3 bool) (*http.Response, error) {
+	quotaWaits, limited := 0, 0
+
+	for {
+		key, ok := s.keys.Current()
+		if !ok {
+			return nil, errVTAuth
+		}
+
+		if err := s.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		if v3 {
+			req.Header.Set("x-apikey", key)
+		} else {
+			q := req.URL.Query()
+			q.Set("apikey", key)
+			req.URL.RawQuery = q.Encode()
+		}
+
+		resp, err := s.doRotating(ctx, client, req, s.keys)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resp.StatusCode {
+		case 200:
+			return resp, nil
+		case 401:
+			resp.Body.Close()
+			if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+				continue
+			}
+			return nil, errVTAuth
+		case 403:
+			resp.Body.Close()
+			if v3 {
+				// Premium-only relationship; another free key would not help
+				return nil, errVTForbidden
+			}
+			if _, ok := s.keys.Rotate(key, resp.StatusCode); ok {
+				continue
+			}
+			return nil, errVTAuth
+		case 204, 429:
+			// v2 signals quota with 204, v3 with 429. The key is fine again
+			// next minute, so move on without retiring it; once every key
+			// has been limited, wait for the window to roll over and try the
+			// same request again.
+			resp.Body.Close()
+			if limited++; limited < s.keys.Usable() {
+				s.keys.Advance(key)
+				continue
+			}
+			if quotaWaits >= vtMaxQuotaWaits {
+				return nil, fmt.Errorf("quota exhausted")
+			}
+			quotaWaits, limited = quotaWaits+1, 0
+			if !sleepCtx(ctx, time.Minute) {
+				return nil, ctx.Err()
+			}
+		default:
+			resp.Body.Close()
+			return nil, fmt.Errorf("bad status: %d", resp.StatusCode)
 		}
-		cursor = vtResp.Links.Next
 	}
 }
@@ -0,0 +1,165 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestVirusTotalMode(t *testing.T) {
+	for mode, want := range map[string]string{
+		"SUBDOMAINS": VTModeSubdomains,
+		"urls":       VTModeURLs,
+		"both":       VTModeBoth,
+		"":           VTModeBoth,
+		"everything": VTModeBoth,
+	} {
+		var cfg config.Config
+		cfg.Sources.VirusTotal.Mode = mode
+		if got := NewVirusTotal("example.com", cfg).mode; got != want {
+			t.Errorf("mode %q: expected %q, got %q", mode, want, got)
+		}
+	}
+}
+
+// vtServer serves two pages of subdomains, a premium-only v3 URL
+// relationship and the v2 domain report.
+func vtServer(t *testing.T, paths *[]string) *httptest.Server {
+	var mu sync.Mutex
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		*paths = append(*paths, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/api/v3/domains/example.com/subdomains":
+			if r.Header.Get("x-apikey") != "k1" {
+				t.Errorf("v3: unexpected key header %q", r.Header.Get("x-apikey"))
+			}
+			if r.URL.Query().Get("cursor") == "" {
+				fmt.Fprintf(w, `{"data": [{"id": "a.example.com"}], "links": {"next": "%s/api/v3/domains/example.com/subdomains?cursor=2"}}`, srv.URL)
+				return
+			}
+			fmt.Fprint(w, `{"data": [{"id": "b.example.com"}], "links": {}}`)
+		case "/api/v3/domains/example.com/urls":
+			w.WriteHeader(http.StatusForbidden)
+		case "/vtapi/v2/domain/report":
+			if q := r.URL.Query(); q.Get("apikey") != "k1" || q.Get("domain") != "example.com" {
+				t.Errorf("v2: unexpected query %q", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"response_code": 1,
+				"undetected_urls": [["https://example.com/u", "sha256", 0, 70, "2023-05-06 07:08:09"]],
+				"detected_urls": [{"url": "https://example.com/d", "scan_date": "2022-01-02 03:04:05"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv
+}
+
+func runVirusTotal(t *testing.T, mode string) ([]appCtx.ScanRecord, []string) {
+	t.Helper()
+
+	var paths []string
+	srv := vtServer(t, &paths)
+	defer srv.Close()
+
+	cfg := config.Config{
+		ApiKeys:    config.ApiKeys{VirusTotal: []string{"k1"}},
+		RateLimits: map[string]config.RateLimit{"virustotal": {}},
+	}
+	cfg.Sources.VirusTotal.Mode = mode
+	s := NewVirusTotal("example.com", cfg)
+	s.baseURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var records []appCtx.ScanRecord
+	for r := range results {
+		records = append(records, r)
+	}
+	return records, paths
+}
+
+func TestVirusTotalSubdomainsMode(t *testing.T) {
+	records, paths := runVirusTotal(t, VTModeSubdomains)
+
+	var urls []string
+	for _, r := range records {
+		urls = append(urls, r.URL)
+	}
+	if want := []string{"a.example.com", "b.example.com"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+	for _, p := range paths {
+		if p != "/api/v3/domains/example.com/subdomains" {
+			t.Errorf("subdomains mode requested %s", p)
+		}
+	}
+}
+
+func TestVirusTotalURLsFallBackToV2(t *testing.T) {
+	records, paths := runVirusTotal(t, VTModeURLs)
+
+	if want := []string{"/api/v3/domains/example.com/urls", "/vtapi/v2/domain/report"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected requests %v, got %v", want, paths)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	want := map[string]time.Time{
+		"https://example.com/u": time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC),
+		"https://example.com/d": time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	for _, r := range records {
+		if at, ok := want[r.URL]; !ok || !r.CapturedAt.Equal(at) {
+			t.Errorf("Unexpected record %s captured at %v", r.URL, r.CapturedAt)
+		}
+	}
+}
+
+func TestVirusTotalQuotaKeepsKey(t *testing.T) {
+	var limited sync.Once
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit := false
+		if r.Header.Get("x-apikey") == "k1" {
+			limited.Do(func() { hit = true })
+		}
+		if hit {
+			w.WriteHeader(http.StatusTooManyRequests) // per-minute quota
+			return
+		}
+		fmt.Fprint(w, `{"data": [{"id": "a.example.com"}], "links": {}}`)
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{
+		ApiKeys:    config.ApiKeys{VirusTotal: []string{"k1", "k2"}},
+		RateLimits: map[string]config.RateLimit{"virustotal": {}},
+	}
+	cfg.Sources.VirusTotal.Mode = VTModeSubdomains
+	s := NewVirusTotal("example.com", cfg)
+	s.baseURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	if len(results) != 1 {
+		t.Errorf("Expected the request to succeed on the spare key, got %d records", len(results))
+	}
+	if key, _ := s.keys.Current(); key != "k2" || s.keys.Usable() != 2 {
+		t.Errorf("Expected k2 active and k1 kept for later, got %q with %d usable", key, s.keys.Usable())
+	}
+}
@@ -5,17 +5,65 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/bratyabasu07/deflot/internal/config"
 	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+const (
+	waybackCDXURL          = "http://web.archive.org/cdx/search/cdx"
+	defaultWaybackPageSize = 10000
+	// cdxTimeLayout is the 14-digit capture timestamp used throughout CDX.
+	cdxTimeLayout = "20060102150405"
+)
+
+func init() {
+	Register(Info{
+		Name:           "wayback",
+		Description:    "Wayback Machine CDX archive",
+		DefaultEnabled: true,
+	}, func(domain string, cfg config.Config) Source {
+		return NewWayback(domain, cfg)
+	})
+}
+
 type Wayback struct {
-	domain string
+	metered
+	domain   string
+	cdxURL   string
+	from     string
+	to       string
+	filters  []string
+	pageSize int
+
+	pages int
 }
 
-func NewWayback(domain string) *Wayback {
-	return &Wayback{domain: domain}
+func NewWayback(domain string, cfg config.Config) *Wayback {
+	settings := cfg.Sources.Wayback
+
+	pageSize := settings.PageSize
+	if pageSize < 1 {
+		pageSize = defaultWaybackPageSize
+	}
+
+	filters := cdxFilters("statuscode", settings.StatusCodes)
+	filters = append(filters, cdxFilters("mimetype", settings.MimeTypes)...)
+
+	return &Wayback{
+		metered:  newMetered("wayback", cfg),
+		domain:   domain,
+		cdxURL:   waybackCDXURL,
+		from:     cdxTimestamp("from", settings.From),
+		to:       cdxTimestamp("to", settings.To),
+		filters:  filters,
+		pageSize: pageSize,
+	}
 }
 
 func (s *Wayback) Name() string {
@@ -26,60 +74,201 @@ func (s *Wayback) NeedsKey() bool {
 	return false
 }
 
-func (s *Wayback) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
-	// Wayback CDX API
-	// Using output=txt for stream-friendly processing
-	apiURL := fmt.Sprintf("http://web.archive.org/cdx/search/cdx?url=*.%s/*&output=txt&fl=original&collapse=urlkey", s.domain)
+// Test checks that the endpoint answers.
+func (s *Wayback) Test(ctx context.Context) error {
+	apiURL := fmt.Sprintf("%s?url=%s&output=txt&fl=original&limit=1", s.cdxURL, s.domain)
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+	return probe(req)
+}
 
+func (s *Wayback) Run(ctx context.Context, results chan<- appCtx.ScanRecord) {
 	client := &http.Client{
-		Timeout: 45 * time.Second, // Increased timeout for stability
+		Timeout: 45 * time.Second, // Per page, so large domains no longer hit it
 	}
 
-	err := WithRetry(func() error {
+	emitted := 0
+	s.pages = 0
+	// Progress goes to stderr so it never mixes into --stdout output
+	defer func() {
+		fmt.Fprintf(os.Stderr, "[*] Wayback: fetched %d pages (%d captures)\n", s.pages, emitted)
+	}()
+
+	resumeKey := ""
+	for {
+		if !s.acquire(ctx, "Wayback") {
+			return
+		}
+
+		next, n, err := s.fetchPage(ctx, client, resumeKey, results)
+		emitted += n
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "[!] Wayback page %d failed: %v\n", s.pages+1, err)
+			}
+			return
+		}
+		s.pages++
+
+		if next == "" {
+			return
+		}
+		resumeKey = next
+	}
+}
+
+// fetchPage streams one CDX page and returns the key for the next one.
+// A stream that breaks halfway is fetched again from the same resumeKey;
+// the few lines emitted twice are absorbed by the Deduplicator.
+func (s *Wayback) fetchPage(ctx context.Context, client *http.Client, resumeKey string, results chan<- appCtx.ScanRecord) (string, int, error) {
+	apiURL := s.cdxURL + "?" + s.query(resumeKey).Encode()
+	emitted := 0
+
+	for attempt := 0; ; attempt++ {
 		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
-			return err // Context error or bad URL
+			return "", emitted, err
 		}
 
-		resp, err := client.Do(req)
+		resp, err := s.do(ctx, client, req)
 		if err != nil {
-			return err
+			return "", emitted, err
 		}
-		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
-			return fmt.Errorf("bad status: %d", resp.StatusCode)
+			resp.Body.Close()
+			return "", emitted, fmt.Errorf("bad status: %d", resp.StatusCode)
 		}
 
-		// Stream results directly inside the retry block?
-		// No, if we stream valid data and THEN fail, we might duplicate on retry?
-		// But CDX failure usually happens at connection start.
-		// For a streaming API, 'WithRetry' is tricky.
-		// If we read 50% lines and fail, retrying might duplicate.
-		// However, for Recon, better to retry and maybe have duplicates (Deduplicator handles it) than missing data.
-
-		scanner := bufio.NewScanner(resp.Body)
-		// Increase buffer size for long URLs
-		buf := make([]byte, 0, 64*1024)
-		scanner.Buffer(buf, 1024*1024)
-
-		for scanner.Scan() {
-			raw := scanner.Text()
-			if raw == "" {
-				continue
-			}
+		next, n, err := s.streamPage(ctx, resp, results)
+		resp.Body.Close()
+		emitted += n
+		if err == nil || ctx.Err() != nil || attempt >= maxRetries {
+			return next, emitted, err
+		}
 
-			select {
-			case <-ctx.Done():
-				return nil // Stop processing
-			case results <- appCtx.ScanRecord{URL: raw, Source: "wayback", Category: "none"}:
+		if !sleepCtx(ctx, backoff(attempt)) {
+			return "", emitted, ctx.Err()
+		}
+		if err := s.limiter.Wait(ctx); err != nil {
+			return "", emitted, err
+		}
+		s.retries.Add(1)
+	}
+}
+
+// streamPage emits the captures in a txt CDX response. With showResumeKey
+// the page ends with a blank line followed by the key for the next page.
+func (s *Wayback) streamPage(ctx context.Context, resp *http.Response, results chan<- appCtx.ScanRecord) (string, int, error) {
+	scanner := bufio.NewScanner(resp.Body)
+	// Increase buffer size for long URLs
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	emitted := 0
+	resumeKey := ""
+	trailer := false
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			trailer = true
+			continue
+		}
+		if trailer {
+			resumeKey = line
+			continue
+		}
+
+		record := appCtx.ScanRecord{URL: line, Source: "wayback", Category: "none"}
+		// fl=original,timestamp; the timestamp never contains spaces
+		if i := strings.LastIndexByte(line, ' '); i > 0 {
+			record.URL = line[:i]
+			if t, err := time.Parse(cdxTimeLayout, line[i+1:]); err == nil {
+				record.CapturedAt = t
 			}
 		}
 
-		return scanner.Err()
-	}, 3)
+		select {
+		case <-ctx.Done():
+			return "", emitted, ctx.Err()
+		case results <- record:
+		}
+		emitted++
+	}
 
-	if err != nil {
-		fmt.Printf("[!] Wayback Failed after retries: %v\n", err)
+	return resumeKey, emitted, scanner.Err()
+}
+
+// query builds the CDX parameters for one page.
+func (s *Wayback) query(resumeKey string) url.Values {
+	q := url.Values{}
+	q.Set("url", "*."+s.domain+"/*")
+	q.Set("output", "txt")
+	q.Set("fl", "original,timestamp")
+	q.Set("collapse", "urlkey")
+	q.Set("limit", strconv.Itoa(s.pageSize))
+	q.Set("showResumeKey", "true")
+	if s.from != "" {
+		q.Set("from", s.from)
+	}
+	if s.to != "" {
+		q.Set("to", s.to)
+	}
+	for _, f := range s.filters {
+		q.Add("filter", f)
+	}
+	if resumeKey != "" {
+		q.Set("resumeKey", resumeKey)
+	}
+	return q
+}
+
+// cdxFilters turns config values into CDX filter expressions. Positive
+// values are OR-ed into one regex, since separate CDX filters are AND-ed;
+// "!" values become one exclusion each.
+func cdxFilters(field string, values []string) []string {
+	var include, filters []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		switch {
+		case v == "" || v == "!":
+			continue
+		case strings.HasPrefix(v, "!"):
+			filters = append(filters, "!"+field+":"+v[1:])
+		default:
+			include = append(include, v)
+		}
+	}
+
+	switch len(include) {
+	case 0:
+	case 1:
+		filters = append(filters, field+":"+include[0])
+	default:
+		filters = append(filters, field+":("+strings.Join(include, "|")+")")
+	}
+	return filters
+}
+
+// cdxTimestamp reduces a date like "2021-06-01" or "2021-06-01T12:00:00"
+// to the digit prefix CDX expects. Invalid values are ignored with a warning.
+func cdxTimestamp(name, value string) string {
+	digits := strings.Map(func(r rune) rune {
+		if strings.ContainsRune("-:T ", r) {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(value))
+
+	if digits == "" {
+		return ""
+	}
+	if len(digits) > len(cdxTimeLayout) || strings.Trim(digits, "0123456789") != "" {
+		fmt.Fprintf(os.Stderr, "[!] Wayback: ignoring invalid %s timestamp %q\n", name, value)
+		return ""
 	}
+	return digits
 }
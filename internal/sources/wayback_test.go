@@ -0,0 +1,79 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/bratyabasu07/deflot/internal/config"
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
+)
+
+func TestWaybackResumePaging(t *testing.T) {
+	var mu sync.Mutex
+	var resumeKeys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		resumeKeys = append(resumeKeys, q.Get("resumeKey"))
+		mu.Unlock()
+		if q.Get("showResumeKey") != "true" || q.Get("from") != "2020" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+
+		switch q.Get("resumeKey") {
+		case "":
+			fmt.Fprint(w, "http://a.example.com/ 20200101000000\nhttp://b.example.com/x 20210615120000\n\nkey-2\n")
+		case "key-2":
+			fmt.Fprint(w, "http://c.example.com/ 20220101000000\n")
+		default:
+			t.Errorf("unexpected resumeKey %q", q.Get("resumeKey"))
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{
+		Sources: config.SourceSettings{Wayback: config.WaybackSettings{From: "2020", PageSize: 2}},
+	}
+	s := NewWayback("example.com", cfg)
+	s.cdxURL = srv.URL
+
+	results := make(chan appCtx.ScanRecord, 10)
+	s.Run(context.Background(), results)
+	close(results)
+
+	var urls []string
+	var years []int
+	for r := range results {
+		urls = append(urls, r.URL)
+		years = append(years, r.CapturedAt.Year())
+	}
+
+	if want := []string{"http://a.example.com/", "http://b.example.com/x", "http://c.example.com/"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Expected %v, got %v", want, urls)
+	}
+	if want := []int{2020, 2021, 2022}; !reflect.DeepEqual(years, want) {
+		t.Errorf("Expected capture years %v, got %v", want, years)
+	}
+	// The second page is asked for with the key the first one ended on,
+	// and the run stops once a page comes back without one
+	if want := []string{"", "key-2"}; !reflect.DeepEqual(resumeKeys, want) {
+		t.Errorf("Expected pages requested with resume keys %q, got %q", want, resumeKeys)
+	}
+}
+
+func TestCDXFilters(t *testing.T) {
+	got := cdxFilters("statuscode", []string{"200", "3..", "!404", ""})
+	want := []string{"!statuscode:404", "statuscode:(200|3..)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	if ts := cdxTimestamp("from", "2021-06-01T12:00"); ts != "202106011200" {
+		t.Errorf("Expected 202106011200, got %q", ts)
+	}
+}
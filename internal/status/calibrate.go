@@ -0,0 +1,200 @@
+package status
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// calibrationPaths are the shapes of nonexistent paths requested per host;
+// {} is replaced by a random token.
+var calibrationPaths = []string{"/{}", "/{}/{}", "/{}.html"}
+
+// fingerprint summarizes a response so pages that only differ in the
+// reflected path or a timestamp still compare equal.
+type fingerprint struct {
+	status int
+	length int64
+	words  int
+	lines  int
+	hash   [sha1.Size]byte
+	title  string
+}
+
+// String renders a fingerprint for the summary.
+func (f fingerprint) String() string {
+	s := fmt.Sprintf("%d, %d bytes", f.status, f.length)
+	if f.title != "" {
+		s += fmt.Sprintf(", %q", f.title)
+	}
+	return s
+}
+
+// matches compares two fingerprints: same status, and either the same body
+// or a length within a few percent plus the same title. Without a title
+// (JS, JSON, binaries) the length alone says little, so the word and line
+// counts must match instead.
+func (f fingerprint) matches(o fingerprint) bool {
+	if f.status != o.status {
+		return false
+	}
+	if f.hash == o.hash {
+		return true
+	}
+
+	diff := f.length - o.length
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > max(16, max(f.length, o.length)/20) {
+		return false
+	}
+	if f.title == "" || o.title == "" {
+		return f.words == o.words && f.lines == o.lines
+	}
+	return f.title == o.title
+}
+
+func makeFingerprint(status int, body []byte, reflected []string) fingerprint {
+	// Error pages often echo the requested path; drop it before comparing
+	for _, r := range reflected {
+		if len(r) > 1 {
+			body = bytes.ReplaceAll(body, []byte(r), nil)
+		}
+	}
+	return fingerprint{
+		status: status,
+		length: int64(len(body)),
+		words:  len(bytes.Fields(body)),
+		lines:  bytes.Count(body, []byte("\n")),
+		hash:   sha1.Sum(body),
+		title:  pageTitle(body),
+	}
+}
+
+// hostCalibration is the cached "not found" behaviour of one host.
+type hostCalibration struct {
+	once     sync.Once
+	notFound []fingerprint // empty when the host answers 404 properly
+	dropped  atomic.Uint64
+}
+
+// HostCalibration reports what calibration found for one host.
+type HostCalibration struct {
+	Host string
+	// CatchAll is set when random paths did not get a 404/410.
+	CatchAll    bool
+	Fingerprint string
+	// Dropped counts URLs that matched the host's "not found" response.
+	Dropped uint64
+}
+
+// softNotFound reports whether a response looks like the host's answer to
+// paths that do not exist, calibrating the host first if needed.
+func (c *Checker) softNotFound(rawURL string, status int, body []byte) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	key := u.Scheme + "://" + u.Host
+	entry, _ := c.calibrations.LoadOrStore(key, &hostCalibration{})
+	cal := entry.(*hostCalibration)
+	cal.once.Do(func() { cal.notFound = c.calibrate(key) })
+
+	if len(cal.notFound) == 0 {
+		return false
+	}
+
+	fp := makeFingerprint(status, body, []string{u.EscapedPath(), u.Path, path.Base(u.Path)})
+	for _, nf := range cal.notFound {
+		if fp.matches(nf) {
+			cal.dropped.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// calibrate requests a few random paths on base and returns the
+// fingerprints of those that did not come back 404/410.
+func (c *Checker) calibrate(base string) []fingerprint {
+	var notFound []fingerprint
+	for _, shape := range calibrationPaths {
+		p := shape
+		var tokens []string
+		for strings.Contains(p, "{}") {
+			tok := randomToken()
+			tokens = append(tokens, tok)
+			p = strings.Replace(p, "{}", tok, 1)
+		}
+
+		// A path-limited scope may not cover random paths on the host
+		if u, err := url.Parse(base + p); err != nil || !c.allowed(u) {
+			continue
+		}
+
+		resp, err := c.client.Get(base + p)
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		resp.Body.Close()
+
+		if resp.StatusCode == 404 || resp.StatusCode == 410 {
+			continue
+		}
+
+		fp := makeFingerprint(resp.StatusCode, body, append([]string{p}, tokens...))
+		known := false
+		for _, nf := range notFound {
+			known = known || nf.matches(fp)
+		}
+		if !known {
+			notFound = append(notFound, fp)
+		}
+	}
+	return notFound
+}
+
+// Calibrations returns the per-host calibration results of this run,
+// catch-all hosts first.
+func (c *Checker) Calibrations() []HostCalibration {
+	var out []HostCalibration
+	c.calibrations.Range(func(k, v any) bool {
+		cal := v.(*hostCalibration)
+		h := HostCalibration{Host: k.(string), Dropped: cal.dropped.Load()}
+		if len(cal.notFound) > 0 {
+			h.CatchAll = true
+			h.Fingerprint = cal.notFound[0].String()
+		}
+		out = append(out, h)
+		return true
+	})
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CatchAll != out[j].CatchAll {
+			return out[i].CatchAll
+		}
+		if out[i].Dropped != out[j].Dropped {
+			return out[i].Dropped > out[j].Dropped
+		}
+		return out[i].Host < out[j].Host
+	})
+	return out
+}
+
+func randomToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,87 @@
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCalibrationDropsCatchAllPages(t *testing.T) {
+	catchAll := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/admin":
+			fmt.Fprint(w, "<title>Admin</title><p>Dashboard with real content</p>")
+		case "/login":
+			fmt.Fprintf(w, "<title>Sign in</title><p>Rendered at %d</p>", time.Now().UnixNano())
+		default:
+			// Everything else bounces to the login page
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	}))
+	defer catchAll.Close()
+
+	proper := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/about" {
+			fmt.Fprint(w, "about")
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer proper.Close()
+
+	c := New(5, []string{"200"}, false, Filters{AutoCalibrate: true})
+
+	if v, _ := c.Check(catchAll.URL + "/admin"); v != Passed {
+		t.Error("Expected a real page on a catch-all host to pass")
+	}
+	for _, p := range []string{"/backup.zip", "/.env"} {
+		if v, _ := c.Check(catchAll.URL + p); v != SoftNotFound {
+			t.Errorf("Expected %s to be dropped as a soft 404", p)
+		}
+	}
+	if v, _ := c.Check(proper.URL + "/about"); v != Passed {
+		t.Error("Expected a page on a host with real 404s to pass")
+	}
+
+	cals := c.Calibrations()
+	if len(cals) != 2 {
+		t.Fatalf("Expected 2 calibrated hosts, got %+v", cals)
+	}
+	if !cals[0].CatchAll || cals[0].Host != catchAll.URL || cals[0].Dropped != 2 {
+		t.Errorf("Unexpected catch-all calibration: %+v", cals[0])
+	}
+	if cals[1].CatchAll {
+		t.Errorf("Expected %s not to be a catch-all: %+v", proper.URL, cals[1])
+	}
+}
+
+func TestFingerprintIgnoresReflectedPath(t *testing.T) {
+	page := func(p string) []byte {
+		return []byte("<title>Oops</title><p>The page " + p + " could not be found.</p>")
+	}
+
+	a := makeFingerprint(200, page("/3f9c2a1b"), []string{"/3f9c2a1b"})
+	b := makeFingerprint(200, page("/wp-admin/setup.php"), []string{"/wp-admin/setup.php"})
+	if !a.matches(b) {
+		t.Errorf("Expected reflected paths to be ignored: %v vs %v", a, b)
+	}
+	if c := makeFingerprint(301, page("/x"), []string{"/x"}); a.matches(c) {
+		t.Error("Expected a different status not to match")
+	}
+}
+
+func TestFingerprintWithoutTitle(t *testing.T) {
+	// A catch-all returning JSON, and a real script of about the same size
+	notFound := makeFingerprint(200, []byte(`{"error": "not found", "code": 404, "request": "abcdef0123"}`), nil)
+	script := makeFingerprint(200, []byte("var a=1;\nvar b=2;\nfunction f(){return a+b}\nf();\nf();\n"), nil)
+	if notFound.matches(script) {
+		t.Errorf("Expected untitled bodies with different shapes not to match: %v vs %v", notFound, script)
+	}
+
+	other := makeFingerprint(200, []byte(`{"error": "not found", "code": 404, "request": "9876fedcba"}`), nil)
+	if !notFound.matches(other) {
+		t.Errorf("Expected untitled bodies with the same shape to match: %v vs %v", notFound, other)
+	}
+}
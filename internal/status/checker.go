@@ -2,21 +2,77 @@ package status
 
 import (
 	"crypto/tls"
+	"html"
+	"io"
 	"net"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	appCtx "github.com/bratyabasu07/deflot/internal/context"
 )
 
+// maxBodySize caps how much of a page --probe reads.
+const maxBodySize = 1 << 20
+
+var titleRegex = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
 // Checker verifies if a URL is alive and matches status codes.
 type Checker struct {
 	client     *http.Client
 	matchCodes map[int]bool
 	enabled    bool
+	probe      bool
+	filters    Filters
+	inScope    func(*url.URL) bool // nil follows redirects anywhere
+
+	calibrations sync.Map // scheme://host -> *hostCalibration
+}
+
+// Verdict is whether Check let a URL through and, if not, why.
+type Verdict int
+
+const (
+	Passed       Verdict = iota
+	Unreachable          // connection failed or timed out
+	StatusDenied         // status not in --mc, or in --fc
+	Filtered             // dropped by a response filter
+	SoftNotFound         // matched the host's calibrated "not found" page
+)
+
+// Dead reports whether a dropped URL is gone rather than live but
+// unwanted: it could not be reached, or answered 404 or 410.
+func (v Verdict) Dead(res Result) bool {
+	switch v {
+	case Unreachable:
+		return true
+	case StatusDenied:
+		return res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusGone
+	}
+	return false
 }
 
-// New creates a new status checker.
-func New(timeout int, matchCodes []string) *Checker {
+// Result is what checking a URL learned about it.
+type Result struct {
+	StatusCode    int
+	Title         string // --probe only
+	ContentLength int64  // -1 if unknown
+	ContentType   string
+	Server        string
+	// FinalURL and RedirectChain are only set when redirects were followed.
+	FinalURL      string
+	RedirectChain []appCtx.Redirect
+}
+
+// New creates a new status checker. With probe, every URL is fetched with
+// GET so the page title and size can be read, and URLs pass regardless of
+// status unless matchCodes are also given. filters further narrow what
+// passes based on the response.
+func New(timeout int, matchCodes []string, probe bool, filters Filters) *Checker {
 	// If no match codes provided, we don't need to check liveness explicitly?
 	// Or do we assume 200 OK by default?
 	// Architecture says "async probe | live | forbidden | historical".
@@ -24,7 +80,7 @@ func New(timeout int, matchCodes []string) *Checker {
 	// But the prompt implies this IS a gate.
 	// If --mc is empty, we set enabled = false (skip check).
 
-	enabled := len(matchCodes) > 0
+	enabled := len(matchCodes) > 0 || probe || filters.active()
 
 	matchMap := make(map[int]bool)
 	for _, c := range matchCodes {
@@ -45,30 +101,105 @@ func New(timeout int, matchCodes []string) *Checker {
 		}).DialContext,
 	}
 
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(timeout) * time.Second,
-		// Don't follow redirects too far, or maybe check redirect status?
-		// We'll stick to default redirect following for now, but usually status check = final status.
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 3 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
-	}
-
-	return &Checker{
-		client:     client,
+	c := &Checker{
 		matchCodes: matchMap,
 		enabled:    enabled,
+		probe:      probe,
+		filters:    filters,
 	}
+	c.client = &http.Client{
+		Transport:     transport,
+		Timeout:       time.Duration(timeout) * time.Second,
+		CheckRedirect: c.checkRedirect,
+	}
+	return c
+}
+
+// SetScope stops redirects (and calibration requests) that would leave the
+// program's scope, so out-of-scope hosts are never contacted. The redirect
+// response itself is then what Check judges.
+func (c *Checker) SetScope(inScope func(*url.URL) bool) {
+	c.inScope = inScope
+}
+
+// checkRedirect follows at most three hops, none of them out of scope.
+func (c *Checker) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 3 || !c.allowed(req.URL) {
+		return http.ErrUseLastResponse
+	}
+	return nil
+}
+
+func (c *Checker) allowed(u *url.URL) bool {
+	return c.inScope == nil || c.inScope(u)
 }
 
-// Check probes the URL. Returns (passed, statusCode).
-func (c *Checker) Check(url string) (bool, int) {
+// Check probes the URL. Returns whether it passed (or why not) and what
+// was learned.
+func (c *Checker) Check(url string) (Verdict, Result) {
 	if !c.enabled {
-		return true, 0 // Passthrough if logic not requested
+		return Passed, Result{} // Passthrough if logic not requested
+	}
+
+	resp, err := c.fetch(url)
+	if err != nil {
+		// --probe on its own only describes URLs, so historical ones it
+		// cannot reach are kept, just without details
+		if c.probeOnly() {
+			return Passed, Result{}
+		}
+		return Unreachable, Result{}
+	}
+	defer resp.Body.Close()
+
+	res := describe(resp)
+
+	// 2. Match Status
+	// The status must be in matchCodes (when given) and not in --fc.
+	if len(c.matchCodes) > 0 && !c.matchCodes[resp.StatusCode] || c.filters.FilterCodes[resp.StatusCode] {
+		return StatusDenied, res
+	}
+
+	// 3. Match Content
+	var body []byte
+	if c.readsBody() {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+		if res.ContentLength < 0 {
+			res.ContentLength = int64(len(body))
+		}
+		if c.probe {
+			res.Title = pageTitle(body)
+		}
+	}
+
+	if !c.filters.pass(resp.Header, body, res.ContentLength) {
+		return Filtered, res
+	}
+
+	// 4. Soft-404: the host answers random paths the same way
+	if c.filters.AutoCalibrate && c.softNotFound(url, resp.StatusCode, body) {
+		return SoftNotFound, res
+	}
+
+	return Passed, res
+}
+
+// probeOnly reports whether --probe is the only check, so no status or
+// response filter asks for URLs to be dropped.
+func (c *Checker) probeOnly() bool {
+	return c.probe && len(c.matchCodes) == 0 && !c.filters.active()
+}
+
+// readsBody reports whether checks need the response body, and so GET.
+func (c *Checker) readsBody() bool {
+	return c.probe || c.filters.needsBody()
+}
+
+// fetch sends the probe request: GET when the body is needed, otherwise
+// HEAD with a GET fallback.
+func (c *Checker) fetch(url string) (*http.Response, error) {
+	if c.readsBody() {
+		return c.client.Get(url)
 	}
 
 	// 1. HEAD request
@@ -100,18 +231,49 @@ func (c *Checker) Check(url string) (bool, int) {
 	}
 
 	if shouldFallback {
-		resp, err = c.client.Get(url)
-		if err != nil {
-			return false, 0 // Connection failed or timeout
+		return c.client.Get(url)
+	}
+	return resp, nil
+}
+
+// describe collects the header-level details of a response, including the
+// redirects the client followed to get there.
+func describe(resp *http.Response) Result {
+	res := Result{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+		Server:        resp.Header.Get("Server"),
+	}
+
+	// Each request made for a redirect points back at the response that caused it
+	var chain []appCtx.Redirect
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append(chain, appCtx.Redirect{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+	if len(chain) > 0 {
+		for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+			chain[i], chain[j] = chain[j], chain[i]
 		}
+		res.RedirectChain = chain
+		res.FinalURL = resp.Request.URL.String()
 	}
-	defer resp.Body.Close()
+	return res
+}
 
-	// 2. Match Status
-	// If matchCodes contains the status, return true.
-	if c.matchCodes[resp.StatusCode] {
-		return true, resp.StatusCode
+// pageTitle extracts the <title> of an HTML page, tidied for one-line output.
+func pageTitle(body []byte) string {
+	m := titleRegex.FindSubmatch(body)
+	if m == nil {
+		return ""
 	}
 
-	return false, resp.StatusCode
+	title := strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+	if runes := []rune(title); len(runes) > 200 {
+		title = string(runes[:200])
+	}
+	return title
 }
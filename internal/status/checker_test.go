@@ -0,0 +1,116 @@
+package status
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+)
+
+func TestCheckProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/login", http.StatusMovedPermanently)
+		case "/login":
+			w.Header().Set("Server", "nginx")
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>\n  Sign in &amp; continue </title></head></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(5, nil, true, Filters{})
+	v, res := c.Check(srv.URL + "/old")
+	if v != Passed {
+		t.Fatal("Expected --probe without --mc to pass every status")
+	}
+
+	if res.StatusCode != 200 || res.Title != "Sign in & continue" || res.Server != "nginx" || res.ContentType != "text/html" {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+	if res.ContentLength <= 0 {
+		t.Errorf("Expected a content length, got %d", res.ContentLength)
+	}
+	if res.FinalURL != srv.URL+"/login" {
+		t.Errorf("Expected final URL %s/login, got %s", srv.URL, res.FinalURL)
+	}
+	if len(res.RedirectChain) != 1 || res.RedirectChain[0].URL != srv.URL+"/old" || res.RedirectChain[0].StatusCode != 301 {
+		t.Errorf("Unexpected redirect chain: %+v", res.RedirectChain)
+	}
+}
+
+func TestCheckStopsOutOfScopeRedirect(t *testing.T) {
+	outside := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Out-of-scope host contacted: %s", r.URL)
+	}))
+	defer outside.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, outside.URL+"/landing", http.StatusFound)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	c := New(5, nil, true, Filters{})
+	c.SetScope(func(u *url.URL) bool { return u.Host == target.Host })
+
+	v, res := c.Check(srv.URL + "/old")
+	if v != Passed || res.StatusCode != http.StatusFound || res.FinalURL != "" {
+		t.Errorf("Expected the redirect itself to be judged, got %v %+v", v, res)
+	}
+}
+
+func TestCheckProbeKeepsUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	dead := srv.URL + "/backup.zip"
+	srv.Close()
+
+	if v, res := New(2, nil, true, Filters{}).Check(dead); v != Passed || res.StatusCode != 0 {
+		t.Errorf("Expected --probe alone to keep an unreachable URL without details, got %v %+v", v, res)
+	}
+	if v, res := New(2, []string{"200"}, true, Filters{}).Check(dead); v != Unreachable || !v.Dead(res) {
+		t.Errorf("Expected --mc to drop an unreachable URL as dead, got %v", v)
+	}
+}
+
+func TestCheckMatchCodes(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := New(5, []string{"200"}, false, Filters{})
+	v, res := c.Check(srv.URL + "/missing")
+	if v != StatusDenied || res.StatusCode != 404 || !v.Dead(res) {
+		t.Errorf("Expected 404 to fail --mc 200 as dead, got %v status=%d", v, res.StatusCode)
+	}
+	if res.RedirectChain != nil || res.FinalURL != "" {
+		t.Errorf("Expected no redirect details, got %+v", res)
+	}
+}
+
+func TestCheckFiltersGenericErrorPage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin" {
+			w.Write([]byte("<h1>Admin panel</h1>"))
+			return
+		}
+		w.Write([]byte("<h1>Sorry, this page does not exist</h1>")) // 200 for everything
+	}))
+	defer srv.Close()
+
+	c := New(5, []string{"200"}, false, Filters{
+		FilterCodes: map[int]bool{404: true},
+		FilterRegex: regexp.MustCompile(`does not exist`),
+	})
+
+	if v, _ := c.Check(srv.URL + "/admin"); v != Passed {
+		t.Error("Expected the real page to pass")
+	}
+	// The page is live, so it is not worth an archive lookup
+	if v, res := c.Check(srv.URL + "/nothing"); v != Filtered || v.Dead(res) {
+		t.Errorf("Expected the generic error page to be filtered but not dead, got %v", v)
+	}
+}
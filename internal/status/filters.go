@@ -0,0 +1,157 @@
+package status
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive numeric range; Max < 0 means no upper bound.
+type Range struct {
+	Min, Max int64
+}
+
+func (r Range) contains(v int64) bool {
+	return v >= r.Min && (r.Max < 0 || v <= r.Max)
+}
+
+// ParseRanges parses a comma-separated list like "0,100-200,5000-".
+func ParseRanges(s string) ([]Range, error) {
+	var ranges []Range
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		lo, hi, isRange := strings.Cut(part, "-")
+		r := Range{Max: -1}
+		var err error
+		if lo != "" {
+			if r.Min, err = strconv.ParseInt(lo, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		switch {
+		case !isRange:
+			r.Max = r.Min
+		case hi != "":
+			if r.Max, err = strconv.ParseInt(hi, 10, 64); err != nil || r.Max < r.Min {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func inRanges(v int64, ranges []Range) bool {
+	for _, r := range ranges {
+		if r.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filters narrow the status gate by response content. Every Match* that is
+// set must hold and no Filter* may, so a URL passes only if it fits all of
+// them. The zero value filters nothing.
+type Filters struct {
+	FilterCodes map[int]bool
+
+	MatchSize, FilterSize   []Range // bytes
+	MatchWords, FilterWords []Range
+	MatchLines, FilterLines []Range
+
+	MatchRegex, FilterRegex   *regexp.Regexp // response body
+	MatchHeader, FilterHeader *regexp.Regexp // "Name: value" lines
+
+	// AutoCalibrate requests a few random paths on each new host and drops
+	// responses that look like its answer to those (soft 404s, catch-alls).
+	AutoCalibrate bool
+}
+
+// ParseCodes parses a comma-separated list of status codes.
+func ParseCodes(s string) (map[int]bool, error) {
+	codes := make(map[int]bool)
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		code, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status code %q", part)
+		}
+		codes[code] = true
+	}
+	return codes, nil
+}
+
+// active reports whether any filter is set.
+func (f Filters) active() bool {
+	return len(f.FilterCodes) > 0 || f.needsBody() || f.MatchHeader != nil || f.FilterHeader != nil
+}
+
+// needsBody reports whether the filters look past the headers, which
+// means probing with GET.
+func (f Filters) needsBody() bool {
+	return len(f.MatchSize) > 0 || len(f.FilterSize) > 0 ||
+		len(f.MatchWords) > 0 || len(f.FilterWords) > 0 ||
+		len(f.MatchLines) > 0 || len(f.FilterLines) > 0 ||
+		f.MatchRegex != nil || f.FilterRegex != nil || f.AutoCalibrate
+}
+
+// pass applies the content filters to a response. size is the content
+// length (or bytes read when the server did not say).
+func (f Filters) pass(header http.Header, body []byte, size int64) bool {
+	if !f.checkRanges(size, f.MatchSize, f.FilterSize) {
+		return false
+	}
+
+	if len(f.MatchWords) > 0 || len(f.FilterWords) > 0 {
+		words := int64(len(bytes.Fields(body)))
+		if !f.checkRanges(words, f.MatchWords, f.FilterWords) {
+			return false
+		}
+	}
+
+	if len(f.MatchLines) > 0 || len(f.FilterLines) > 0 {
+		var lines int64
+		if len(body) > 0 {
+			lines = int64(bytes.Count(body, []byte("\n"))) + 1
+		}
+		if !f.checkRanges(lines, f.MatchLines, f.FilterLines) {
+			return false
+		}
+	}
+
+	if f.MatchRegex != nil && !f.MatchRegex.Match(body) {
+		return false
+	}
+	if f.FilterRegex != nil && f.FilterRegex.Match(body) {
+		return false
+	}
+
+	if f.MatchHeader != nil || f.FilterHeader != nil {
+		var buf bytes.Buffer
+		header.Write(&buf)
+		if f.MatchHeader != nil && !f.MatchHeader.Match(buf.Bytes()) {
+			return false
+		}
+		if f.FilterHeader != nil && f.FilterHeader.Match(buf.Bytes()) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filters) checkRanges(v int64, match, filter []Range) bool {
+	if len(match) > 0 && !inRanges(v, match) {
+		return false
+	}
+	return !inRanges(v, filter)
+}
@@ -0,0 +1,52 @@
+package status
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestParseRanges(t *testing.T) {
+	got, err := ParseRanges("0, 100-200,5000-,-10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Range{{0, 0}, {100, 200}, {5000, -1}, {0, 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	for _, bad := range []string{"abc", "200-100", "1-x"} {
+		if _, err := ParseRanges(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestFiltersPass(t *testing.T) {
+	body := []byte("Oops\nPage not found\n")
+	header := http.Header{"Server": []string{"cloudflare"}}
+
+	tests := []struct {
+		name    string
+		filters Filters
+		want    bool
+	}{
+		{"none", Filters{}, true},
+		{"size match", Filters{MatchSize: []Range{{10, 30}}}, true},
+		{"size filtered", Filters{FilterSize: []Range{{20, 20}}}, false},
+		{"words", Filters{MatchWords: []Range{{4, 4}}}, true},
+		{"lines filtered", Filters{FilterLines: []Range{{3, 3}}}, false},
+		{"body regex filtered", Filters{FilterRegex: regexp.MustCompile(`(?i)not found`)}, false},
+		{"body regex match", Filters{MatchRegex: regexp.MustCompile(`admin`)}, false},
+		{"header match", Filters{MatchHeader: regexp.MustCompile(`(?i)server: cloudflare`)}, true},
+		{"header filtered", Filters{FilterHeader: regexp.MustCompile(`cloudflare`)}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filters.pass(header, body, int64(len(body))); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package summary
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,7 +22,49 @@ type Stats struct {
 	Params     uint64
 	JS         uint64
 
-	mu sync.Mutex
+	// Snapshots recovered from the archive for dead URLs
+	Archived uint64
+
+	// Cross-run history (only reported when a history store is used)
+	history      bool
+	NewURLs      uint64
+	PreviousURLs uint64
+
+	// Dedup backend footprint
+	dedupMode      string
+	dedupBytes     uint64
+	dedupFPRate    float64
+	dedupSaturated bool
+
+	// Structural dedup (only reported when enabled)
+	templates uint64
+	collapsed uint64
+
+	// URLs logged to out_of_scope.txt (-1 when not logging)
+	outOfScope int
+
+	mu          sync.Mutex
+	sourceUsage []SourceUsage
+	rejected    map[string]uint64 // reason -> records
+
+	// Soft-404 calibration (only reported when enabled)
+	calibratedHosts int
+	catchAllHosts   []CatchAllHost
+}
+
+// CatchAllHost is a host whose "not found" page is not a 404.
+type CatchAllHost struct {
+	Host        string
+	Fingerprint string
+	Dropped     uint64
+}
+
+// SourceUsage is one source's API consumption for the final report.
+type SourceUsage struct {
+	Name           string
+	Calls          uint64
+	Retries        uint64
+	QuotaExhausted bool
 }
 
 // Global instance or per-pipeline?
@@ -30,7 +73,8 @@ type Stats struct {
 
 func New() *Stats {
 	return &Stats{
-		StartTime: time.Now(),
+		StartTime:  time.Now(),
+		outOfScope: -1,
 	}
 }
 
@@ -50,7 +94,7 @@ func (s *Stats) IncCategory(cat string) {
 	// Atomic maps are hard, using mutex for map usually,
 	// or specific counters. Given fixed categories, specific counters are faster.
 	switch cat {
-	case "secret":
+	case "secret", "credential":
 		atomic.AddUint64(&s.Secrets, 1)
 	case "config", "backup", "vcs", "cloud":
 		atomic.AddUint64(&s.Sensitives, 1) // grouping other sensitives
@@ -61,6 +105,64 @@ func (s *Stats) IncCategory(cat string) {
 	}
 }
 
+// IncRejected counts a record that failed URL validation.
+func (s *Stats) IncRejected(reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.rejected == nil {
+		s.rejected = make(map[string]uint64)
+	}
+	s.rejected[reason]++
+}
+
+func (s *Stats) IncArchived() {
+	atomic.AddUint64(&s.Archived, 1)
+}
+
+// SetHistory records how many unique URLs were new versus already seen
+// by earlier scans.
+func (s *Stats) SetHistory(newURLs, previous uint64) {
+	s.history = true
+	s.NewURLs = newURLs
+	s.PreviousURLs = previous
+}
+
+// SetDedupMemory records the dedup backend and its estimated memory use.
+func (s *Stats) SetDedupMemory(mode string, bytes uint64, fpRate float64, saturated bool) {
+	s.dedupMode = mode
+	s.dedupBytes = bytes
+	s.dedupFPRate = fpRate
+	s.dedupSaturated = saturated
+}
+
+// SetTemplates records how many structural templates were seen and how
+// many URLs were dropped for exceeding their template's samples.
+func (s *Stats) SetTemplates(templates, collapsed uint64) {
+	s.templates = templates
+	s.collapsed = collapsed
+}
+
+// SetOutOfScope records how many distinct URLs the scope rules dropped.
+func (s *Stats) SetOutOfScope(n int) {
+	s.outOfScope = n
+}
+
+// SetCalibration records how many hosts were calibrated for soft 404s and
+// which of them turned out to be catch-alls.
+func (s *Stats) SetCalibration(hosts int, catchAll []CatchAllHost) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calibratedHosts = hosts
+	s.catchAllHosts = catchAll
+}
+
+// AddSourceUsage records how many API calls a source made.
+func (s *Stats) AddSourceUsage(u SourceUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sourceUsage = append(s.sourceUsage, u)
+}
+
 // PrintReport outputs the final summary to stdout.
 func (s *Stats) PrintReport() {
 	duration := time.Since(s.StartTime)
@@ -72,11 +174,76 @@ func (s *Stats) PrintReport() {
 	fmt.Printf("Total URLs    : %d\n", atomic.LoadUint64(&s.TotalURLs))
 	fmt.Printf("Unique (Dedup): %d\n", atomic.LoadUint64(&s.PassedDedup))
 	fmt.Printf("Live (Status) : %d\n", atomic.LoadUint64(&s.PassedStatus))
+	if s.dedupMode != "" {
+		note := s.dedupMode
+		if s.dedupMode == "bloom" {
+			note += fmt.Sprintf(", est. FP %.3f%%", s.dedupFPRate*100)
+		}
+		if s.dedupSaturated {
+			note += ", memory ceiling reached"
+		}
+		fmt.Printf("Dedup Memory  : ~%.1f MB (%s)\n", float64(s.dedupBytes)/(1<<20), note)
+	}
+	if s.templates > 0 {
+		fmt.Printf("Templates     : %d (%d URLs collapsed)\n", s.templates, s.collapsed)
+	}
+	if s.outOfScope >= 0 {
+		fmt.Printf("Out of Scope  : %d URLs (see out_of_scope.txt)\n", s.outOfScope)
+	}
+	if s.history {
+		fmt.Printf("New (History) : %d (%d seen in earlier scans)\n", s.NewURLs, s.PreviousURLs)
+	}
 	fmt.Println("----------------------------------------")
 	fmt.Println("Classification:")
 	fmt.Printf("  - Secrets   : %d\n", atomic.LoadUint64(&s.Secrets))
 	fmt.Printf("  - Sensitive : %d\n", atomic.LoadUint64(&s.Sensitives))
 	fmt.Printf("  - Params    : %d\n", atomic.LoadUint64(&s.Params))
 	fmt.Printf("  - JS Files  : %d\n", atomic.LoadUint64(&s.JS))
+	if archived := atomic.LoadUint64(&s.Archived); archived > 0 {
+		fmt.Printf("Archived      : %d snapshots of dead URLs\n", archived)
+	}
+
+	s.mu.Lock()
+	if s.calibratedHosts > 0 {
+		var dropped uint64
+		for _, h := range s.catchAllHosts {
+			dropped += h.Dropped
+		}
+
+		fmt.Println("----------------------------------------")
+		fmt.Printf("Soft-404: %d of %d hosts are catch-all (%d URLs dropped)\n", len(s.catchAllHosts), s.calibratedHosts, dropped)
+		for _, h := range s.catchAllHosts {
+			fmt.Printf("  - %s: %s, %d dropped\n", h.Host, h.Fingerprint, h.Dropped)
+		}
+	}
+	if len(s.rejected) > 0 {
+		reasons := make([]string, 0, len(s.rejected))
+		for reason := range s.rejected {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+
+		fmt.Println("----------------------------------------")
+		fmt.Println("Rejected URLs:")
+		for _, reason := range reasons {
+			fmt.Printf("  - %-18s: %d\n", reason, s.rejected[reason])
+		}
+	}
+	if len(s.sourceUsage) > 0 {
+		fmt.Println("----------------------------------------")
+		fmt.Println("API Calls:")
+		for _, u := range s.sourceUsage {
+			note := ""
+			if u.Retries > 0 {
+				note += fmt.Sprintf(" (%d retries)", u.Retries)
+			}
+			if u.QuotaExhausted {
+				note += " (quota exhausted)"
+			}
+			fmt.Printf("  - %-10s: %d%s\n", u.Name, u.Calls, note)
+		}
+	}
+	s.mu.Unlock()
+
 	fmt.Println("========================================")
 }
@@ -19,6 +19,8 @@ type Flasher struct {
 	seenJS      uint32
 	seenLog     uint32
 	seenArchive uint32
+
+	seenCredential uint32
 }
 
 // NewFlasher creates a new flasher.
@@ -39,6 +41,8 @@ func (f *Flasher) Notify(category string) {
 	switch category {
 	case filters.CatSecret:
 		ptr = &f.seenSecret
+	case filters.CatCredential:
+		ptr = &f.seenCredential
 	case filters.CatConfig:
 		ptr = &f.seenConfig
 	case filters.CatCloud: